@@ -2,12 +2,13 @@ package aggregator
 
 import (
 	"crypto/ecdsa"
-	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/transition"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,7 +21,10 @@ type AggregatorNode struct {
 	accountsTrie     optimisticrp.Optimistic
 	ethContract      optimisticrp.OptimisticSContract
 	privKey          *ecdsa.PrivateKey
+	sync             *transition.Syncer
 	onChainRoot      common.Hash
+	rootInterval     int //record the state root every rootInterval transactions, 0 disables intermediate roots
+	witness          *optimisticrp.MultiProof
 	log              *logrus.Entry
 }
 
@@ -32,30 +36,30 @@ func New(newAccountsTrie optimisticrp.Optimistic, newEthContract optimisticrp.Op
 		accountsTrie: newAccountsTrie,
 		ethContract:  newEthContract,
 		privKey:      privateKey,
+		sync:         transition.NewSyncer(newAccountsTrie, newEthContract, aggregatorLogger),
 		log:          aggregatorLogger,
 	}
 }
 
-//Sync with on-chain smart contract
+//Sync with on-chain smart contract, the deposits and withdraws of the next batch are always taken from the last confirmed block
 func (ag *AggregatorNode) Synced() (bool, error) {
 	onChainStateRoot, err := ag.onChainStateRoot()
 	if err != nil {
 		return false, err
 	}
-	if onChainStateRoot == ag.accountsTrie.StateRoot() {
-		return true, nil
-	}
-	stateRoot, pendingDeposits, err := ag.computeAccountsTrie()
+	checkpoints, err := ag.sync.Sync()
 	if err != nil {
 		return false, err
 	}
-	ag.pendingDeposits = pendingDeposits
-	ag.log.WithFields(logrus.Fields{"StateRoot": stateRoot}).Info("Computed accounts state")
+	checkpoint := checkpoints[len(checkpoints)-1]
+	ag.pendingDeposits = checkpoint.Deposits
+	ag.pendingWithdraws = checkpoint.Withdraws
+	ag.log.WithFields(logrus.Fields{"StateRoot": checkpoint.LastRoot}).Info("Computed accounts state")
 	ag.log.WithFields(logrus.Fields{"StateRoot": onChainStateRoot}).Info("OnChain accounts state")
-	if stateRoot != onChainStateRoot {
+	if checkpoint.LastRoot != onChainStateRoot {
 		return false, fmt.Errorf("Aggregator was not able to compute a valid StateRoot")
 	}
-	return true, nil
+	return true, ag.sync.WriteCheckpoints(checkpoints)
 }
 
 //if sendBatch succeeds we should notify all user transactions
@@ -64,54 +68,128 @@ func (ag *AggregatorNode) sendBatch() error {
 	if err != nil {
 		return err
 	}
-	for _, deposit := range ag.pendingDeposits {
-		err := ag.addFunds(deposit.From, deposit.Value)
-		if err != nil {
-			return err
-		}
-	}
-	for _, withdraw := range ag.pendingWithdraws {
-		err := ag.removeFunds(withdraw.From, withdraw.Value)
-		if err != nil {
-			return err
-		}
-	}
-	for _, tx := range ag.transactions {
-		_, err := ag.maliciousProcessTx(tx)
-		if err != nil {
-			return err
-		}
+	//the witness is taken before the batch touches the accounts, it also proves the accounts of the transactions that will be dropped
+	ag.witness, err = transition.NewWitness(ag.accountsTrie, ag.pendingDeposits, ag.pendingWithdraws, optimisticrp.Batch{Transactions: ag.transactions, Submitter: ag.Address()})
+	if err != nil {
+		ag.log.WithFields(logrus.Fields{"Error": err}).Debug("Batch witness not generated")
 	}
-	b := optimisticrp.Batch{
-		PrevStateRoot: prevStateRoot,
-		StateRoot:     ag.accountsTrie.StateRoot(),
+	//the accounts are left untouched if the batch is not sent, its transactions are kept for the next try
+	snapshot := ag.accountsTrie.Snapshot()
+	b, err := ag.applyTransactions(prevStateRoot)
+	if err != nil {
+		ag.accountsTrie.RevertToSnapshot(snapshot)
+		return err
 	}
-	b.StateRoot = ag.accountsTrie.StateRoot()
-	b.Transactions = ag.transactions
 	txOpts, err := ag.ethContract.PrepareTxOptions(big.NewInt(0), big.NewInt(2), big.NewInt(2), ag.privKey)
 	if err != nil {
+		ag.accountsTrie.RevertToSnapshot(snapshot)
 		return err
 	}
 	_, err = ag.ethContract.NewBatch(b.SolidityFormat(), txOpts)
 	if err != nil {
+		ag.accountsTrie.RevertToSnapshot(snapshot)
 		return err
 	}
+	ag.accountsTrie.DiscardSnapshot(snapshot)
+	ag.log.WithFields(logrus.Fields{"TxRoot": b.TxRoot, "Transactions": len(b.Transactions), "Dropped": len(ag.transactions) - len(b.Transactions)}).Info("Batch sent")
+	//the batch applied them, the next ones are read by Synced
 	ag.transactions = nil
-	return err
+	ag.pendingDeposits = nil
+	ag.pendingWithdraws = nil
+	lastBatch, err := ag.lastBatch()
+	if err != nil {
+		return err
+	}
+	return ag.sync.Commit(lastBatch + 1)
+}
+
+//applyTransactions applies the pending deposits and withdraws and the received transactions and returns the batch to send
+//A transaction that fails is reverted and dropped from the batch with the next ones of its sender, their nonces would not match
+func (ag *AggregatorNode) applyTransactions(prevStateRoot common.Hash) (*optimisticrp.Batch, error) {
+	if err := transition.ApplyOnChainData(ag.accountsTrie, ag.pendingDeposits, ag.pendingWithdraws); err != nil {
+		return nil, err
+	}
+	b := &optimisticrp.Batch{PrevStateRoot: prevStateRoot, Submitter: ag.Address()}
+	dropped := make(map[common.Address]bool)
+	for _, tx := range ag.transactions {
+		if dropped[tx.From] {
+			ag.log.WithFields(logrus.Fields{"From": tx.From, "Nonce": tx.Nonce}).Warn("Transaction dropped after a failed one of its sender")
+			continue
+		}
+		snapshot := ag.accountsTrie.Snapshot()
+		root, err := ag.maliciousProcessTx(tx)
+		if err != nil {
+			ag.accountsTrie.RevertToSnapshot(snapshot)
+			dropped[tx.From] = true
+			ag.log.WithFields(logrus.Fields{"From": tx.From, "Nonce": tx.Nonce, "Error": err}).Warn("Transaction dropped from the batch")
+			continue
+		}
+		ag.accountsTrie.DiscardSnapshot(snapshot)
+		b.Transactions = append(b.Transactions, tx)
+		if ag.rootInterval > 0 && len(b.Transactions)%ag.rootInterval == 0 {
+			b.IntermediateRoots = append(b.IntermediateRoots, optimisticrp.IntermediateRoot{Index: uint64(len(b.Transactions) - 1), Root: root})
+		}
+	}
+	b.StateRoot = ag.accountsTrie.StateRoot()
+	var err error
+	b.TxRoot, err = b.ComputeTxRoot()
+	return b, err
+}
+
+//SetRootInterval makes the next batches commit the state root after every k transactions, k = 1 records a root per transaction
+//Challengers use them to pinpoint the first invalid transition. 0 disables them
+func (ag *AggregatorNode) SetRootInterval(k int) {
+	ag.rootInterval = k
 }
 
+//Witness returns the proof of the accounts touched by the last sent batch at its previous state root, nil if the state does not support multiproofs
+//Anyone can check the batch with transition.VerifyWitness without the accounts state
+func (ag *AggregatorNode) Witness() *optimisticrp.MultiProof {
+	return ag.witness
+}
+
+//ActualNonce returns the nonce the next transaction of acc must have, transactions waiting for the next batch are included
 func (ag *AggregatorNode) ActualNonce(acc common.Address) (uint64, error) {
+	nonce := uint64(0)
 	val, err := ag.accountsTrie.GetAccount(acc)
-	if err != nil {
-		return 0, nil
+	if err == nil {
+		nonce = val.Nonce
 	}
-	return val.Nonce, nil
+	for _, tx := range ag.transactions {
+		if tx.From == acc {
+			nonce++
+		}
+	}
+	return nonce, nil
 }
 
 func (ag *AggregatorNode) ReceiveTransaction(tx optimisticrp.Transaction) error {
+	if !transition.KnownTxType(tx.Type) {
+		return &optimisticrp.UnknownTxType{Type: tx.Type}
+	}
+	if err := transition.CheckAmounts(tx); err != nil {
+		return err
+	}
+	signer, err := ag.sync.Signer()
+	if err != nil {
+		return err
+	}
+	if err := optimisticrp.VerifySender(signer, &tx); err != nil {
+		return err
+	}
+	nonce, err := ag.ActualNonce(tx.From)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce != nonce {
+		return &optimisticrp.InvalidNonce{Addr: tx.From, Expected: nonce, Got: tx.Nonce}
+	}
+	if err := ag.checkFunds(tx); err != nil {
+		return err
+	}
 	ag.transactions = append(ag.transactions, tx)
 	ag.log.WithFields(logrus.Fields{"From": tx.From, "To": tx.To, "Value:": tx.Value}).Debug("Appended transaction")
-	if len(ag.transactions) == MAX_TRANSACTIONS_BATCH {
+	if len(ag.transactions) >= MAX_TRANSACTIONS_BATCH {
 		ag.log.Info("Preparing and sending batch")
 		if ok, err := ag.Synced(); ok {
 			err := ag.sendBatch()
@@ -125,157 +203,127 @@ func (ag *AggregatorNode) ReceiveTransaction(tx optimisticrp.Transaction) error
 	return nil
 }
 
+//checkFunds checks that the sender balance, with its pending deposits and withdraws, covers the value plus the fee of the
+//transaction and of its other transactions waiting for the next batch. Transfers are only checked for the sender account
+//as this node processes them without checking the balances (see maliciousProcessTx)
+func (ag *AggregatorNode) checkFunds(tx optimisticrp.Transaction) error {
+	account, err := ag.accountsTrie.GetAccount(tx.From)
+	if err != nil && !ag.pendingDeposit(tx.From) {
+		return err
+	}
+	if tx.Type == optimisticrp.TransferTxType {
+		return nil
+	}
+	balance := func(token common.Address) *big.Int {
+		total := new(big.Int)
+		if err == nil {
+			total.Set(account.BalanceOf(token))
+		}
+		for _, deposit := range ag.pendingDeposits {
+			if deposit.From == tx.From && deposit.Token == token {
+				total.Add(total, deposit.Value)
+			}
+		}
+		for _, withdraw := range ag.pendingWithdraws {
+			if withdraw.From == tx.From && withdraw.Token == token {
+				total.Sub(total, withdraw.Value)
+			}
+		}
+		return total
+	}
+	cost, value := tx.Cost(), new(big.Int)
+	if tx.Token != optimisticrp.Ether {
+		value.Set(tx.Value)
+	}
+	for _, pending := range ag.transactions {
+		if pending.From != tx.From {
+			continue
+		}
+		cost.Add(cost, pending.Cost())
+		if tx.Token != optimisticrp.Ether && pending.Token == tx.Token {
+			value.Add(value, pending.Value)
+		}
+	}
+	if total := balance(optimisticrp.Ether); total.Cmp(cost) < 0 {
+		return &optimisticrp.InvalidBalance{Addr: tx.From, Token: optimisticrp.Ether, Total: total}
+	}
+	if tx.Token != optimisticrp.Ether {
+		if total := balance(tx.Token); total.Cmp(value) < 0 {
+			return &optimisticrp.InvalidBalance{Addr: tx.From, Token: tx.Token, Total: total}
+		}
+	}
+	return nil
+}
+
+//pendingDeposit reports whether the account has a deposit that will be applied by the next batch
+func (ag *AggregatorNode) pendingDeposit(addr common.Address) bool {
+	for _, deposit := range ag.pendingDeposits {
+		if deposit.From == addr {
+			return true
+		}
+	}
+	return false
+}
+
 //Should be private
 func (ag *AggregatorNode) onChainStateRoot() (common.Hash, error) {
 	return ag.ethContract.GetStateRoot()
 }
 
-//Malicious processTx which won't check if amount is negative
+//Malicious processTx which won't check if amount is negative, the transaction fee is credited to the aggregator
+//Other transaction types are applied following the rules
 func (ag *AggregatorNode) maliciousProcessTx(transaction optimisticrp.Transaction) (common.Hash, error) {
+	if transaction.Type != optimisticrp.TransferTxType {
+		signer, err := ag.sync.Signer()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return transition.ProcessTx(ag.accountsTrie, signer, ag.Address(), transaction)
+	}
 	fromAcc, err := ag.accountsTrie.GetAccount(transaction.From)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	toAcc, err := ag.accountsTrie.GetAccount(transaction.To)
-	switch err.(type) {
-	case nil:
-	case *optimisticrp.AccountNotFound:
-		toAcc = optimisticrp.Account{Balance: new(big.Int).SetUint64(0), Nonce: 0}
-		ag.accountsTrie.UpdateAccount(transaction.To, toAcc)
-	default:
-		return common.Hash{}, err
-	}
-	if fromAcc.Balance.Cmp(transaction.Value) == -1 {
+	cost := transaction.Cost()
+	if fromAcc.Balance.Cmp(cost) == -1 {
 		ag.log.Warn("I am a malicious node, and that balance is negative but I won't check it")
 		//setting balance to value as negative big.int cannot be rlp decoded
-		fromAcc.Balance.Add(fromAcc.Balance, transaction.Value)
+		fromAcc.Balance.Add(fromAcc.Balance, cost)
+	}
+	fromAcc.Balance.Sub(fromAcc.Balance, cost)
+	if transaction.Token != optimisticrp.Ether {
+		tokenBalance := fromAcc.BalanceOf(transaction.Token)
+		if tokenBalance.Cmp(transaction.Value) == -1 {
+			ag.log.Warn("I am a malicious node, and that token balance is negative but I won't check it")
+			tokenBalance.Add(tokenBalance, transaction.Value)
+		}
+		fromAcc.SetBalanceOf(transaction.Token, tokenBalance.Sub(tokenBalance, transaction.Value))
 	}
-	fromAcc.Balance.Sub(fromAcc.Balance, transaction.Value)
-	toAcc.Balance.Add(toAcc.Balance, transaction.Value)
 	fromAcc.Nonce++
 	ag.accountsTrie.UpdateAccount(transaction.From, fromAcc)
 	ag.log.WithFields(logrus.Fields{"Sender": transaction.From, "Remaining balance": fromAcc.Balance}).Debug("Processed transaction")
-	return ag.accountsTrie.UpdateAccount(transaction.To, toAcc), nil
-}
-
-func (ag *AggregatorNode) addFunds(account common.Address, value *big.Int) error {
-	acc, err := ag.accountsTrie.GetAccount(account)
-	switch err.(type) {
-	case nil:
-	case *optimisticrp.AccountNotFound:
-		newAcc := optimisticrp.Account{Balance: value, Nonce: 0}
-		ag.accountsTrie.UpdateAccount(account, newAcc)
-		return nil
-	default:
-		return err
+	if err := transition.AddFunds(ag.accountsTrie, transaction.Recipient(), transaction.Token, transaction.Value); err != nil {
+		return common.Hash{}, err
 	}
-	acc.Balance.Add(acc.Balance, value)
-	ag.accountsTrie.UpdateAccount(account, acc)
-	return nil
-}
-
-func (ag *AggregatorNode) removeFunds(account common.Address, value *big.Int) error {
-	acc, err := ag.accountsTrie.GetAccount(account)
-	switch err.(type) {
-	case nil:
-	case *optimisticrp.AccountNotFound:
-		newAcc := optimisticrp.Account{Balance: value, Nonce: 0}
-		ag.accountsTrie.UpdateAccount(account, newAcc)
-		return nil
-	default:
-		return err
+	if fee := transaction.Fee(); fee.Sign() > 0 {
+		if err := transition.AddFunds(ag.accountsTrie, ag.Address(), optimisticrp.Ether, fee); err != nil {
+			return common.Hash{}, err
+		}
 	}
-	acc.Balance.Sub(acc.Balance, value)
-	ag.accountsTrie.UpdateAccount(account, acc)
-	return nil
+	return ag.accountsTrie.StateRoot(), nil
 }
 
-//Reads all transactions to the smart contracts and computes the whole accounts trie from scratch
-func (ag *AggregatorNode) computeAccountsTrie() (common.Hash, []optimisticrp.Deposit, error) {
-	optimisticTrie, ok := ag.accountsTrie.(*optimisticrp.OptimisticTrie)
-	if ok != true {
-		return common.Hash{}, nil, fmt.Errorf("This challenger implementation uses the OptimisticTrie object, if you are not, please develop your own challenger functions")
-	}
-	onChainData := make(chan interface{})
-	go ag.ethContract.GetOnChainData(onChainData)
-	stateRoot := common.Hash{}
-	pendingDeposits := []optimisticrp.Deposit{}
-	for methodData := range onChainData {
-		switch input := methodData.(type) {
-		case optimisticrp.SolidityBatch:
-			batch, err := input.ToGolangFormat()
-			if err != nil {
-				return stateRoot, nil, err
-			}
-			ag.log.Info("New onChain Batch received")
-			//if there is a new batch we MUST update the stateRoot with the previous deposits (rule 1.)
-			isValid, err := ag.ethContract.IsStateRootValid(batch.StateRoot)
-			if err != nil {
-				return stateRoot, nil, err
-			}
-			onChainStateRoot, err := ag.ethContract.GetStateRoot()
-			if err != nil {
-				return stateRoot, nil, err
-			}
-			ag.log.Trace("Updating accounts state with new deposits")
-			for _, deposit := range pendingDeposits {
-				err := optimisticTrie.AddFunds(deposit.From, deposit.Value)
-				if err != nil {
-					return stateRoot, nil, err
-				}
-			}
-			pendingDeposits = nil
-			ag.log.Trace("Updating accounts state with last withdraws")
-			for _, withdraw := range ag.pendingWithdraws {
-				err := optimisticTrie.RemoveFunds(withdraw.From, withdraw.Value)
-				if err != nil {
-					return stateRoot, nil, err
-				}
-			}
-			ag.pendingWithdraws = nil
-			if isValid {
-				ag.log.Info("Updating accounts state as the provided batch is valid, it shall not contain any error")
-				for _, txInBatch := range batch.Transactions {
-					stateRoot, err = optimisticTrie.ProcessTx(txInBatch)
-					if err != nil {
-						return stateRoot, nil, err
-					}
-				}
-				//_ = v.sendFraudProof(common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db1E"))
-			} else if !isValid && input.StateRoot == onChainStateRoot {
-				tmpTrie, err := optimisticTrie.Copy()
-				if err != nil {
-					return stateRoot, nil, err
-				}
-				for _, txInBatch := range batch.Transactions {
-					_, err := tmpTrie.ProcessTx(txInBatch)
-					if err != nil {
-						return stateRoot, nil, err
-					}
-				}
-				//If after analyzing all transactions with the temporal Trie we don't get any error we can proceed updating the main Trie
-				ag.log.Info("Last batch is valid but lock time has not expired, updating accounts state...")
-				for _, txInBatch := range batch.Transactions {
-					stateRoot, err = optimisticTrie.ProcessTx(txInBatch)
-					if err != nil {
-						return stateRoot, nil, err
-					}
-				}
-			} else {
-				ag.log.Debug("Skipping invalid onChain batch")
-			}
-		case optimisticrp.Deposit:
-			ag.log.WithFields(logrus.Fields{"Account": input.From, "Value": input.Value}).Info("New onChain deposit")
-			pendingDeposits = append(pendingDeposits, input)
-		case optimisticrp.Withdraw:
-			ag.log.WithFields(logrus.Fields{"Account": input.From, "Value": input.Value}).Info("New onChain withdraw")
-			ag.pendingWithdraws = append(ag.pendingWithdraws, input)
-		case error:
-			return stateRoot, nil, input
+//Address of the aggregator account, it submits the batches and earns the transactions fees
+func (ag *AggregatorNode) Address() common.Address {
+	return crypto.PubkeyToAddress(ag.privKey.PublicKey)
+}
 
-		default:
-			return common.Hash{}, nil, errors.New("There was an error while fetching onChain data")
-		}
+//Batch number of the last committed state, 0 if the Optimistic implementation is not persisted
+func (ag *AggregatorNode) lastBatch() (uint64, error) {
+	committer, ok := ag.accountsTrie.(optimisticrp.Committer)
+	if !ok {
+		return 0, nil
 	}
-	return stateRoot, pendingDeposits, nil
+	head, err := committer.Head()
+	return head.Batch, err
 }
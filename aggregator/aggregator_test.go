@@ -1,6 +1,7 @@
 package aggregator
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"log"
 	"math/big"
@@ -8,12 +9,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/simchain"
+	"github.com/rogercoll/optimisticrp/transition"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,13 +24,27 @@ var addrAccount1 = common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db
 var addrAccount2 = common.HexToAddress("0x9185eAE1c5AD845137AaDf34a955e1D676fE421B")
 var addrAccount3 = common.HexToAddress("0x522fE0423db9de4e8Bb88aF3bF24aBE9B7dBF787")
 var account1 = optimisticrp.Account{Balance: new(big.Int).SetUint64(0), Nonce: 0}
+var privAccount1, _ = crypto.HexToECDSA("ff10aa6af851c1b49b7d3a94611d7823adbcfae76e153fc2757b4108a1dc402d")
+var privAccount2, _ = crypto.HexToECDSA("482254ce62c1473ccbf354bf33e08d71ff09dd2859e4fb8ae08d228fb8b727a5")
+var privAccount3, _ = crypto.HexToECDSA("6be7af0159b0f06c078c583df4f262bffc946dbc50c550667225adf1e27b365e")
+var privAggregator, _ = crypto.HexToECDSA("1a973bd661a29da2a124942e9be644ff2983fd61bf68b23ee8612b9ab8591345")
+
+func signTx(tx optimisticrp.Transaction, privKey *ecdsa.PrivateKey) optimisticrp.Transaction {
+	if tx.Gas == nil {
+		tx.Gas = big.NewInt(0)
+	}
+	signedTx, err := optimisticrp.SignTx(&tx, optimisticrp.NewRollupSigner(big.NewInt(1337), common.Address{}), privKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return *signedTx
+}
 
 type mockBridge struct {
 }
 
-func (m *mockBridge) Client() *ethclient.Client { return nil }
 func (m *mockBridge) GetStateRoot() (common.Hash, error) {
-	return common.HexToHash("0x9968e894a03093c6902640366e457efb26d32ea6363cdad8c05090156bcd8587"), nil
+	return common.HexToHash("0x7fb35ab100aeadd11c8d9571a75d1951490b66c63d26d85d0d41061992ab81d4"), nil
 }
 func (m *mockBridge) NewBatch(optimisticrp.SolidityBatch, *bind.TransactOpts) (*types.Transaction, error) {
 	return nil, nil
@@ -41,10 +57,10 @@ func (m *mockBridge) Withdraw(*bind.TransactOpts, []byte, []byte, []byte, []byte
 }
 func (m *mockBridge) Deposit(*bind.TransactOpts) (*types.Transaction, error) { return nil, nil }
 func (m *mockBridge) Bond(*bind.TransactOpts) (*types.Transaction, error)    { return nil, nil }
+func (m *mockBridge) ChainID() (*big.Int, error)                             { return big.NewInt(1337), nil }
 func (m *mockBridge) OriAddr() common.Address                                { return common.Address{} }
-func (m *mockBridge) GetPendingDeposits(depChannel chan<- interface{}) {
-	defer close(depChannel)
-	depChannel <- optimisticrp.Deposit{addrAccount2, big.NewInt(1e+18)}
+func (m *mockBridge) GetPendingDeposits(ctx context.Context, events chan<- optimisticrp.Event) error {
+	return optimisticrp.SendEvent(ctx, events, optimisticrp.DepositEvent{Deposit: optimisticrp.Deposit{From: addrAccount2, Value: big.NewInt(1e+18)}})
 }
 
 func (m *mockBridge) IsStateRootValid(common.Hash) (bool, error) {
@@ -54,33 +70,39 @@ func (m *mockBridge) IsStateRootValid(common.Hash) (bool, error) {
 func (m *mockBridge) PrepareTxOptions(*big.Int, *big.Int, *big.Int, *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
 	return nil, nil
 }
-func (m *mockBridge) GetOnChainData(txChannel chan<- interface{}) {
-	defer close(txChannel)
+func (m *mockBridge) ConfirmedHead() (uint64, common.Hash, error) {
+	return 1, common.HexToHash("0x01"), nil
+}
+func (m *mockBridge) BlockHash(uint64) (common.Hash, error) { return common.HexToHash("0x01"), nil }
+
+//All the on-chain data is in block 1
+func (m *mockBridge) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if from > 1 || to < 1 {
+		return nil
+	}
 	oneEth := big.NewInt(1e+18)
-	txs := []optimisticrp.SolidityTransaction{
-		{
-			From:  addrAccount1,
-			To:    addrAccount2,
-			Value: math.U256Bytes(oneEth),
-			//Value: big.NewInt(1e+18),
-		},
-		{
-			From:  addrAccount1,
-			To:    addrAccount3,
-			Value: math.U256Bytes(oneEth),
-		},
-	}
-	txs2 := []optimisticrp.SolidityTransaction{
-		{
-			From:  addrAccount3,
-			To:    addrAccount1,
-			Value: math.U256Bytes(big.NewInt(3e+18)),
-		},
-	}
-	txChannel <- optimisticrp.Deposit{addrAccount1, big.NewInt(0).SetUint64(10e+18)}
-	txChannel <- optimisticrp.SolidityBatch{Transactions: txs}
-	txChannel <- optimisticrp.Deposit{addrAccount3, big.NewInt(0).SetUint64(8e+18)}
-	txChannel <- optimisticrp.SolidityBatch{Transactions: txs2}
+	batch := optimisticrp.Batch{Submitter: crypto.PubkeyToAddress(privAggregator.PublicKey), Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: oneEth}, privAccount1),
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount3, Value: oneEth, Nonce: 1}, privAccount1),
+	}}
+	batch2 := optimisticrp.Batch{Submitter: crypto.PubkeyToAddress(privAggregator.PublicKey), Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount3, To: addrAccount1, Value: big.NewInt(3e+18)}, privAccount3),
+	}}
+	at := func(index uint) optimisticrp.EventPosition {
+		return optimisticrp.EventPosition{BlockNumber: 1, BlockHash: common.HexToHash("0x01"), LogIndex: index}
+	}
+	for _, event := range []optimisticrp.Event{
+		optimisticrp.DepositEvent{EventPosition: at(0), Deposit: optimisticrp.Deposit{From: addrAccount1, Value: big.NewInt(0).SetUint64(10e+18)}},
+		optimisticrp.BatchEvent{EventPosition: at(1), Batch: batch.SolidityFormat()},
+		optimisticrp.DepositEvent{EventPosition: at(2), Deposit: optimisticrp.Deposit{From: addrAccount3, Value: big.NewInt(0).SetUint64(8e+18)}},
+		optimisticrp.BatchEvent{EventPosition: at(3), Batch: batch2.SolidityFormat()},
+		optimisticrp.L1Block{Number: 1, Hash: common.HexToHash("0x01")},
+	} {
+		if err := optimisticrp.SendEvent(ctx, events, event); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 func TestMain(m *testing.M) {
 	var (
@@ -94,7 +116,7 @@ func TestMain(m *testing.M) {
 	mockBridgeContract := mockBridge{}
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
-	agg = New(tr, &mockBridgeContract, nil, logger)
+	agg = New(tr, &mockBridgeContract, privAggregator, logger)
 	agg.accountsTrie.UpdateAccount(addrAccount1, account1)
 	m.Run()
 }
@@ -111,10 +133,11 @@ func TestActualNonce(t *testing.T) {
 
 func TestComputeAccountsTrie(t *testing.T) {
 	oldStateRoot := agg.accountsTrie.StateRoot()
-	newStateRoot, _, err := agg.computeAccountsTrie()
+	checkpoints, err := agg.sync.Sync()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
+	newStateRoot := checkpoints[len(checkpoints)-1].LastRoot
 	if newStateRoot == oldStateRoot {
 		t.Errorf("NewStateRoot = %v; must be different than %v", newStateRoot, oldStateRoot)
 	}
@@ -127,12 +150,400 @@ func TestComputeAccountsTrie(t *testing.T) {
 	}
 }
 
+//reorgBridge is a chain where the checkpoint block was replaced
+type reorgBridge struct {
+	mockBridge
+}
+
+func (m *reorgBridge) BlockHash(uint64) (common.Hash, error) { return common.HexToHash("0x02"), nil }
+
+func TestSyncedCheckpoint(t *testing.T) {
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, &mockBridge{}, privAggregator, logger)
+	if ok, err := node.Synced(); !ok {
+		t.Fatal(err)
+	}
+	onChainStateRoot, _ := node.onChainStateRoot()
+	checkpoint, err := tr.Checkpoint()
+	if err != nil || checkpoint == nil {
+		t.Fatalf("Checkpoint = %v, %v; want the synced block", checkpoint, err)
+	}
+	if checkpoint.Block != 1 || checkpoint.Batch != 2 || checkpoint.LastRoot != onChainStateRoot || checkpoint.StateRoot != tr.StateRoot() {
+		t.Errorf("Checkpoint = %+v; want block 1 after 2 batches at %v", checkpoint, onChainStateRoot.Hex())
+	}
+	//uncommitted updates are discarded when resuming, the blocks before the checkpoint are not read again
+	tr.UpdateAccount(addrAccount2, optimisticrp.Account{Balance: big.NewInt(1), Nonce: 7})
+	if ok, err := node.Synced(); !ok {
+		t.Fatal(err)
+	}
+	if tr.StateRoot() != onChainStateRoot {
+		t.Errorf("StateRoot = %v; want the checkpoint root %v", tr.StateRoot().Hex(), onChainStateRoot.Hex())
+	}
+	if checkpoint, discarded, err := transition.LoadCheckpoint(tr, &reorgBridge{}, 1); checkpoint != nil || discarded != 1 || err != nil {
+		t.Errorf("LoadCheckpoint = %v, %d, %v; want the checkpoint of a replaced block discarded", checkpoint, discarded, err)
+	}
+}
+
+//forgedBridge accepted a batch whose only transaction has an invalid signature
+type forgedBridge struct {
+	mockBridge
+	stateRoot common.Hash
+}
+
+func (m *forgedBridge) GetStateRoot() (common.Hash, error) { return m.stateRoot, nil }
+func (m *forgedBridge) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if from > 1 || to < 1 {
+		return nil
+	}
+	batch := optimisticrp.Batch{Submitter: crypto.PubkeyToAddress(privAggregator.PublicKey), StateRoot: m.stateRoot, Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1e+18)}, privAccount3),
+	}}
+	for _, event := range []optimisticrp.Event{
+		optimisticrp.DepositEvent{EventPosition: optimisticrp.EventPosition{BlockNumber: 1}, Deposit: optimisticrp.Deposit{From: addrAccount1, Value: big.NewInt(1e+18)}},
+		optimisticrp.BatchEvent{EventPosition: optimisticrp.EventPosition{BlockNumber: 1, LogIndex: 1}, Batch: batch.SolidityFormat()},
+		optimisticrp.L1Block{Number: 1, Hash: common.HexToHash("0x01")},
+	} {
+		if err := optimisticrp.SendEvent(ctx, events, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSyncedSkipsInvalidSignature(t *testing.T) {
+	//the valid batch leaves the state with only the deposit
+	expected, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transition.AddFunds(expected, addrAccount1, optimisticrp.Ether, big.NewInt(1e+18)); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, &forgedBridge{stateRoot: expected.StateRoot()}, privAggregator, logger)
+	if ok, err := node.Synced(); !ok {
+		t.Fatalf("Synced = %v; want the forged transaction skipped", err)
+	}
+	if _, err := tr.GetAccount(addrAccount2); err == nil {
+		t.Error("Account credited by a transaction with an invalid signature")
+	}
+}
+
+func TestSyncedReorg(t *testing.T) {
+	chain, err := simchain.New(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	newNode := func() (*AggregatorNode, *optimisticrp.OptimisticTrie) {
+		tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return New(tr, chain, privAggregator, logger), tr
+	}
+	deposit := func(privKey *ecdsa.PrivateKey) {
+		txOpts, _ := chain.PrepareTxOptions(big.NewInt(1e+18), nil, nil, privKey)
+		if _, err := chain.Deposit(txOpts); err != nil {
+			t.Fatal(err)
+		}
+		chain.Commit()
+	}
+	//block 1 deposit, block 2 batch spending it and block 3 deposit
+	deposit(privAccount1)
+	sender, _ := newNode()
+	if ok, err := sender.Synced(); !ok {
+		t.Fatal(err)
+	}
+	tx, err := optimisticrp.SignTx(&optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1e+17), Gas: big.NewInt(0)}, optimisticrp.NewRollupSigner(big.NewInt(1337), simchain.ContractAddr), privAccount1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.ReceiveTransaction(*tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.sendBatch(); err != nil {
+		t.Fatal(err)
+	}
+	chain.Commit()
+	deposit(privAccount3)
+
+	node, tr := newNode()
+	if ok, err := node.Synced(); !ok {
+		t.Fatal(err)
+	}
+	if _, err := tr.GetAccount(addrAccount2); err != nil || len(node.pendingDeposits) != 1 {
+		t.Fatalf("Synced state misses the batch or the last deposit: %v, %v", err, node.pendingDeposits)
+	}
+	//blocks 2 and 3 are replaced, the state is rolled back to block 1 where only the first deposit was done
+	if err := chain.Reorg(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*AggregatorNode{node, sender} {
+		if ok, err := n.Synced(); !ok {
+			t.Fatal(err)
+		}
+		if _, err := n.accountsTrie.GetAccount(addrAccount2); err == nil {
+			t.Error("Account credited by a replaced batch")
+		}
+		if len(n.pendingDeposits) != 1 || n.pendingDeposits[0].From != addrAccount1 {
+			t.Errorf("Pending deposits = %v; want the deposit of block 1", n.pendingDeposits)
+		}
+	}
+	checkpoints, err := tr.Checkpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 2 || checkpoints[0].Block != 1 || checkpoints[1].Block != 4 {
+		t.Errorf("Checkpoints = %v; want blocks 1 and 4", checkpoints)
+	}
+}
+
+func TestSendBatchPendingDeposits(t *testing.T) {
+	chain, err := simchain.New(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, chain, privAggregator, logger)
+	deposit := func(privKey *ecdsa.PrivateKey) {
+		txOpts, _ := chain.PrepareTxOptions(big.NewInt(1e+18), nil, nil, privKey)
+		if _, err := chain.Deposit(txOpts); err != nil {
+			t.Fatal(err)
+		}
+		chain.Commit()
+	}
+	sendBatch := func() {
+		if ok, err := node.Synced(); !ok {
+			t.Fatal(err)
+		}
+		if err := node.sendBatch(); err != nil {
+			t.Fatal(err)
+		}
+		chain.Commit()
+	}
+	deposit(privAccount1)
+	sendBatch()
+	if len(node.pendingDeposits) != 0 {
+		t.Errorf("Pending deposits = %v; want none after the batch applied them", node.pendingDeposits)
+	}
+	//the state root matches the on-chain one, the deposit done after the batch must be read anyway
+	deposit(privAccount3)
+	sendBatch()
+	for _, addr := range []common.Address{addrAccount1, addrAccount3} {
+		if acc, err := tr.GetAccount(addr); err != nil || acc.Balance.Cmp(big.NewInt(1e+18)) != 0 {
+			t.Errorf("Account %v = %+v, %v; want each deposit applied once", addr.Hex(), acc, err)
+		}
+	}
+}
+
+//forkingChain replaces every block with a reorg once the node has loaded its checkpoint and starts reading the blocks after it
+type forkingChain struct {
+	*simchain.Chain
+	forked bool
+}
+
+func (f *forkingChain) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if !f.forked {
+		f.forked = true
+		if err := f.Reorg(0, 3); err != nil {
+			return err
+		}
+	}
+	return f.Chain.GetOnChainData(ctx, from, to, events)
+}
+
+func TestSyncedParentHashReorg(t *testing.T) {
+	chain, err := simchain.New(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	txOpts, _ := chain.PrepareTxOptions(big.NewInt(1e+18), nil, nil, privAccount1)
+	if _, err := chain.Deposit(txOpts); err != nil {
+		t.Fatal(err)
+	}
+	chain.Commit()
+	if ok, err := New(tr, chain, privAggregator, logger).Synced(); !ok {
+		t.Fatal(err)
+	}
+	chain.Commit()
+	//the checkpoint of block 1 is still valid when it is loaded, block 2 is read from the new chain
+	node := New(tr, &forkingChain{Chain: chain}, privAggregator, logger)
+	if _, err := node.Synced(); err == nil {
+		t.Fatal("Synced read block 2 of another chain than the checkpoint")
+	} else if reorg, ok := err.(*optimisticrp.Reorg); !ok || reorg.Block != 2 {
+		t.Fatalf("Synced = %v; want a reorg detected at block 2", err)
+	}
+	if ok, err := node.Synced(); !ok {
+		t.Fatal(err)
+	}
+	if len(node.pendingDeposits) != 0 {
+		t.Errorf("Pending deposits = %v; want the deposit dropped by the reorg", node.pendingDeposits)
+	}
+}
+
+func TestSyncedConfirmations(t *testing.T) {
+	chain, err := simchain.New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, chain, privAggregator, logger)
+	txOpts, _ := chain.PrepareTxOptions(big.NewInt(1e+18), nil, nil, privAccount1)
+	if _, err := chain.Deposit(txOpts); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []int{0, 1} {
+		chain.Commit()
+		if ok, err := node.Synced(); !ok {
+			t.Fatal(err)
+		}
+		if len(node.pendingDeposits) != expected {
+			t.Errorf("Pending deposits after %d blocks = %v; want %d", i+1, node.pendingDeposits, expected)
+		}
+	}
+}
+
 func TestSendBatch(t *testing.T) {
 	for i := 0; i < MAX_TRANSACTIONS_BATCH; i++ {
-		tx := optimisticrp.Transaction{Value: big.NewInt(1e+18), Gas: big.NewInt(1e+18), To: addrAccount2, From: addrAccount1}
-		err := agg.ReceiveTransaction(tx)
+		nonce, err := agg.ActualNonce(addrAccount1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := signTx(optimisticrp.Transaction{Value: big.NewInt(1e+18), Gas: big.NewInt(1e+18), To: addrAccount2, From: addrAccount1, Nonce: nonce}, privAccount1)
+		err = agg.ReceiveTransaction(tx)
 		if err != nil {
 			t.Error(err)
 		}
 	}
+	acc, err := agg.accountsTrie.GetAccount(agg.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees := new(big.Int).Mul(big.NewInt(1e+18), big.NewInt(MAX_TRANSACTIONS_BATCH))
+	if acc.Balance.Cmp(fees) != 0 {
+		t.Errorf("Aggregator balance = %v; want the batch fees %v", acc.Balance, fees)
+	}
+	prevStateRoot, _ := agg.onChainStateRoot()
+	witness := agg.Witness()
+	if witness == nil || witness.Root != prevStateRoot || len(witness.Keys) != 3 {
+		t.Fatalf("Batch witness = %v; want the submitter, sender and receiver at %v", witness, prevStateRoot.Hex())
+	}
+	if _, err := optimisticrp.VerifyMultiProof(prevStateRoot, witness); err != nil {
+		t.Error(err)
+	}
+}
+
+//newFundedNode returns an aggregator whose state holds 5 weis for account 1 and account 3
+func newFundedNode(t *testing.T) *AggregatorNode {
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, &mockBridge{}, privAggregator, logger)
+	for _, addr := range []common.Address{addrAccount1, addrAccount3} {
+		if err := transition.AddFunds(tr, addr, optimisticrp.Ether, big.NewInt(5)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return node
+}
+
+func multiSendTx(t *testing.T, total, fee int64, nonce uint64) optimisticrp.Transaction {
+	tx, err := optimisticrp.NewMultiSendTx(addrAccount1, optimisticrp.Ether, []optimisticrp.Payment{{To: addrAccount2, Value: big.NewInt(total)}}, big.NewInt(fee), nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signTx(*tx, privAccount1)
+}
+
+func TestReceiveTransactionFunds(t *testing.T) {
+	node := newFundedNode(t)
+	if _, ok := node.ReceiveTransaction(multiSendTx(t, 4, 2, 0)).(*optimisticrp.InvalidBalance); !ok {
+		t.Errorf("Multi-send whose fee exceeds the balance must return InvalidBalance")
+	}
+	if err := node.ReceiveTransaction(multiSendTx(t, 3, 2, 0)); err != nil {
+		t.Fatal(err)
+	}
+	//the balance is already spent by the pending transaction
+	if _, ok := node.ReceiveTransaction(multiSendTx(t, 1, 0, 1)).(*optimisticrp.InvalidBalance); !ok {
+		t.Errorf("Multi-send above the balance left by the pending transactions must return InvalidBalance")
+	}
+	tx := signTx(optimisticrp.Transaction{From: addrAccount2, To: addrAccount1, Value: big.NewInt(1)}, privAccount2)
+	if _, ok := node.ReceiveTransaction(tx).(*optimisticrp.AccountNotFound); !ok {
+		t.Errorf("Transaction of a missing sender must return AccountNotFound")
+	}
+}
+
+func TestSendBatchDropsFailedTransactions(t *testing.T) {
+	node := newFundedNode(t)
+	//queued without the checks of ReceiveTransaction, the second transaction of account 1 follows the failed one
+	node.transactions = []optimisticrp.Transaction{
+		multiSendTx(t, 10, 0, 0),
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1), Nonce: 1}, privAccount1),
+		signTx(optimisticrp.Transaction{From: addrAccount2, To: addrAccount1, Value: big.NewInt(1)}, privAccount2),
+		signTx(optimisticrp.Transaction{From: addrAccount3, To: addrAccount2, Value: big.NewInt(2)}, privAccount3),
+	}
+	if err := node.sendBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.transactions) != 0 {
+		t.Errorf("Pending transactions = %d; want none after the batch", len(node.transactions))
+	}
+	sender, err := node.accountsTrie.GetAccount(addrAccount1)
+	if err != nil || sender.Balance.Cmp(big.NewInt(5)) != 0 || sender.Nonce != 0 {
+		t.Errorf("Account 1 = %v, %v; want its transactions dropped", sender, err)
+	}
+	if receiver, err := node.accountsTrie.GetAccount(addrAccount2); err != nil || receiver.Balance.Cmp(big.NewInt(2)) != 0 || receiver.Nonce != 0 {
+		t.Errorf("Account 2 = %v, %v; want only the transfer of account 3", receiver, err)
+	}
+}
+
+func TestReceiveTransactionSignature(t *testing.T) {
+	tx := signTx(optimisticrp.Transaction{Value: big.NewInt(1e+18), To: addrAccount2, From: addrAccount1}, privAccount3)
+	err := agg.ReceiveTransaction(tx)
+	if _, ok := err.(*optimisticrp.InvalidSignature); !ok {
+		t.Errorf("Error = %v; want InvalidSignature", err)
+	}
+}
+
+func TestReceiveTransactionNonce(t *testing.T) {
+	nonce, err := agg.ActualNonce(addrAccount1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(optimisticrp.Transaction{Value: big.NewInt(1e+18), To: addrAccount2, From: addrAccount1, Nonce: nonce + 1}, privAccount1)
+	err = agg.ReceiveTransaction(tx)
+	if got, ok := err.(*optimisticrp.InvalidNonce); !ok || got.Expected != nonce {
+		t.Errorf("Error = %v; want InvalidNonce expecting %d", err, nonce)
+	}
 }
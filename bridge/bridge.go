@@ -8,26 +8,50 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/rogercoll/optimisticrp"
 	store "github.com/rogercoll/optimisticrp/contracts"
 	"github.com/sirupsen/logrus"
 )
 
+//Backend is the layer 1 node used by the bridge, *ethclient.Client and backends.SimulatedBackend implement it
+type Backend interface {
+	bind.ContractBackend
+	ethereum.ChainReader
+	ethereum.TransactionReader
+}
+
+//chainIDReader is implemented by the backends that query the chain ID from the node, like ethclient
+type chainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
 type Bridge struct {
 	oriContract *store.Contracts
 	oriAddr     common.Address
-	client      *ethclient.Client
+	client      Backend
 	log         *logrus.Entry
+	//parses the events of both contract variants, token events are only emitted by Optimistic_Rollups_ERC20
+	events      *store.ContractsERC20Filterer
+	contractAbi abi.ABI
+	//encoding of the submitted batches, GetOnChainData decodes all of them
+	batchVersion byte
+	//blocks range read by GetOnChainData and GetPendingDeposits, see SetStartBlock and SetPageSize
+	startBlock uint64
+	pageSize   uint64
+	//blocks on top of the ones read, shallower blocks may be replaced by a reorg
+	confirmations uint64
+	//chain ID of the backends that can not query it, see SetChainID
+	chainID *big.Int
 }
 
-func New(oriAddr common.Address, ethClient *ethclient.Client, logger *logrus.Logger) (*Bridge, error) {
+func New(oriAddr common.Address, ethClient Backend, logger *logrus.Logger) (*Bridge, error) {
 	bridgeLogger := logger.WithFields(logrus.Fields{
 		"service": "Bridge",
 	})
@@ -35,11 +59,15 @@ func New(oriAddr common.Address, ethClient *ethclient.Client, logger *logrus.Log
 	if err != nil {
 		return nil, err
 	}
-	return &Bridge{instance, oriAddr, ethClient, bridgeLogger}, nil
-}
-
-func (b *Bridge) Client() *ethclient.Client {
-	return b.client
+	events, err := store.NewContractsERC20Filterer(oriAddr, ethClient)
+	if err != nil {
+		return nil, err
+	}
+	contractAbi, err := abi.JSON(strings.NewReader(store.ContractsERC20ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &Bridge{instance, oriAddr, ethClient, bridgeLogger, events, contractAbi, optimisticrp.LegacyBatchVersion, 0, DefaultPageSize, 0, nil}, nil
 }
 
 func (b *Bridge) GetStateRoot() (common.Hash, error) {
@@ -50,12 +78,80 @@ func (b *Bridge) GetStateRoot() (common.Hash, error) {
 	return onChainStateRoot, nil
 }
 
+func (b *Bridge) ChainID() (*big.Int, error) {
+	if b.chainID != nil {
+		return b.chainID, nil
+	}
+	reader, ok := b.client.(chainIDReader)
+	if !ok {
+		return nil, fmt.Errorf("%s The backend can not query the chain ID, it must be set with SetChainID", optimisticrp.OPR_BANNER)
+	}
+	return reader.ChainID(context.Background())
+}
+
+//ConfirmedHead returns the number and hash of the block confirmations blocks behind the chain head, the genesis on shorter chains
+func (b *Bridge) ConfirmedHead() (uint64, common.Hash, error) {
+	header, err := b.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	if b.confirmations == 0 {
+		return header.Number.Uint64(), header.Hash(), nil
+	}
+	number := uint64(0)
+	if header.Number.Uint64() > b.confirmations {
+		number = header.Number.Uint64() - b.confirmations
+	}
+	hash, err := b.BlockHash(number)
+	return number, hash, err
+}
+
+func (b *Bridge) BlockHash(number uint64) (common.Hash, error) {
+	header, err := b.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+//SetBatchVersion selects the calldata encoding of the next batches: optimisticrp.LegacyBatchVersion (default, the one prove_fraud parses), CompactBatchVersion or DeflateBatchVersion
+func (b *Bridge) SetBatchVersion(version byte) {
+	b.batchVersion = version
+}
+
+//SetStartBlock skips the blocks before the given one when reading the on-chain data, usually the contract deployment block
+func (b *Bridge) SetStartBlock(block uint64) {
+	b.startBlock = block
+}
+
+//SetPageSize sets the number of blocks requested on each logs query, 0 restores DefaultPageSize
+func (b *Bridge) SetPageSize(blocks uint64) {
+	if blocks == 0 {
+		blocks = DefaultPageSize
+	}
+	b.pageSize = blocks
+}
+
+//SetChainID sets the chain ID signed by the layer 2 transactions instead of querying the backend, the simulated backend uses 1337
+func (b *Bridge) SetChainID(chainID *big.Int) {
+	b.chainID = chainID
+}
+
+//SetConfirmations sets the number of blocks that must be mined on top of a block before its data is read, 0 reads up to the chain head
+func (b *Bridge) SetConfirmations(blocks uint64) {
+	b.confirmations = blocks
+}
+
 func (b *Bridge) NewBatch(batch optimisticrp.SolidityBatch, txOpts *bind.TransactOpts) (*types.Transaction, error) {
-	result, err := rlp.EncodeToBytes(batch)
+	goBatch, err := batch.ToGolangFormat()
+	if err != nil {
+		return nil, err
+	}
+	result, err := optimisticrp.EncodeBatch(&goBatch, b.batchVersion)
 	if err != nil {
 		return nil, err
 	}
-	b.log.WithFields(logrus.Fields{"Bytes": len(result)}).Warn("Batch size")
+	b.log.WithFields(logrus.Fields{"Bytes": len(result), "Version": b.batchVersion}).Debug("Batch size")
 	txresult, err := b.oriContract.NewBatch(txOpts, result)
 	if err != nil {
 		return nil, err
@@ -71,6 +167,7 @@ func (b *Bridge) OriAddr() common.Address {
 func (b *Bridge) FraudProof(txOpts *bind.TransactOpts, address, value, proof, stateRoot []byte, lastBatch optimisticrp.SolidityBatch) (*types.Transaction, error) {
 	var array [32]byte
 	copy(array[:], stateRoot[:32])
+	//prove_fraud only reads the legacy encoding whatever version the batch was submitted in
 	result, err := rlp.EncodeToBytes(lastBatch)
 	if err != nil {
 		return nil, err
@@ -94,6 +191,35 @@ func (b *Bridge) Withdraw(txOpts *bind.TransactOpts, address, value, proof, stat
 	return txresult, nil
 }
 
+//ClaimWithdrawal claims on-chain the ether of the sender withdrawal transaction with the given nonce,
+//proof is the NewProve output of its receipt account (see optimisticrp.NewWithdrawalProof)
+func (b *Bridge) ClaimWithdrawal(txOpts *bind.TransactOpts, nonce uint64, proof [][]byte) (*types.Transaction, error) {
+	var array [32]byte
+	copy(array[:], proof[3][:32])
+	txresult, err := b.oriContract.ClaimWithdrawal(txOpts, new(big.Int).SetUint64(nonce), proof[1], proof[2], array)
+	if err != nil {
+		return nil, err
+	}
+	b.log.Info("Withdrawal claim was successfully submited onChain")
+	return txresult, nil
+}
+
+//ClaimTokenWithdrawal is ClaimWithdrawal for the ERC20 tokens of the withdrawal, only the Optimistic_Rollups_ERC20 variant supports it
+func (b *Bridge) ClaimTokenWithdrawal(txOpts *bind.TransactOpts, token common.Address, nonce uint64, proof [][]byte) (*types.Transaction, error) {
+	var array [32]byte
+	copy(array[:], proof[3][:32])
+	transactor, err := store.NewContractsERC20Transactor(b.oriAddr, b.client)
+	if err != nil {
+		return nil, err
+	}
+	txresult, err := transactor.ClaimTokenWithdrawal(txOpts, token, new(big.Int).SetUint64(nonce), proof[1], proof[2], array)
+	if err != nil {
+		return nil, err
+	}
+	b.log.Info("Token withdrawal claim was successfully submited onChain")
+	return txresult, nil
+}
+
 func (b *Bridge) Bond(txOpts *bind.TransactOpts) (*types.Transaction, error) {
 	txresult, err := b.oriContract.Bond(txOpts)
 	if err != nil {
@@ -128,128 +254,6 @@ func (b *Bridge) IsStateRootValid(state common.Hash) (bool, error) {
 	return validStateRoot, nil
 }
 
-//Reads all transactions to the smart contracts and computes the whole accounts trie from scratch
-//This implementation is used for local chains, few blocks. In production (main chain) you shall use an ingestion service to get all the transactions of a given address.
-func (b *Bridge) GetOnChainData(dataChannel chan<- interface{}) {
-	defer close(dataChannel)
-	header, err := b.client.HeaderByNumber(context.Background(), nil)
-	if err != nil {
-		dataChannel <- err
-	}
-	myAbi, err := abi.JSON(strings.NewReader(store.ContractsABI))
-	if err != nil {
-		dataChannel <- err
-	}
-	b.log.Debug(fmt.Sprintf("Analyzing %v blocks\n", header.Number))
-	for i := int64(0); i <= header.Number.Int64(); i++ {
-		block, err := b.client.BlockByNumber(context.Background(), big.NewInt(i))
-		if err != nil {
-			dataChannel <- err
-		}
-		for _, tx := range block.Transactions() {
-			//if tx.To() == nil => Contract creation
-			if tx.To() != nil && (*(tx.To()) == b.oriAddr) {
-				txReceipt, err := b.client.TransactionReceipt(context.Background(), tx.Hash())
-				if err != nil {
-					dataChannel <- err
-				}
-				if txReceipt.Status == 1 {
-					inputData := tx.Data()
-					sigdata, argdata := inputData[:4], inputData[4:]
-					method, err := myAbi.MethodById(sigdata)
-					if err != nil {
-						dataChannel <- err
-					}
-					if method.Name == "newBatch" {
-						data, err := method.Inputs.UnpackValues(argdata)
-						if err != nil {
-							dataChannel <- err
-						}
-						var batch optimisticrp.SolidityBatch
-						err = rlp.DecodeBytes(data[0].([]byte), &batch)
-						if err != nil {
-							b.log.Warn("Unable to unmarshal batch from transaction")
-							continue
-						}
-						dataChannel <- batch
-					} else if method.Name == "deposit" {
-						msg, err := tx.AsMessage(types.NewEIP155Signer(tx.ChainId()))
-						if err != nil {
-							dataChannel <- err
-						}
-						dataChannel <- optimisticrp.Deposit{msg.From(), tx.Value()}
-					} else if method.Name == "withdraw" {
-						data, err := method.Inputs.UnpackValues(argdata)
-						if err != nil {
-							dataChannel <- err
-						}
-						var acc optimisticrp.SolidityAccount
-						err = rlp.DecodeBytes(data[1].([]byte), &acc)
-						if err != nil {
-							dataChannel <- err
-						}
-						msg, err := tx.AsMessage(types.NewEIP155Signer(tx.ChainId()))
-						if err != nil {
-							dataChannel <- err
-						}
-						goFormat, err := acc.ToGolangFormat()
-						if err != nil {
-							dataChannel <- err
-						}
-						dataChannel <- optimisticrp.Withdraw{msg.From(), goFormat.Balance}
-					}
-				}
-			}
-		}
-	}
-	b.log.Info("All blocks analized")
-}
-
-func (b *Bridge) GetPendingDeposits(depChannel chan<- interface{}) {
-	defer close(depChannel)
-	header, err := b.client.HeaderByNumber(context.Background(), nil)
-	if err != nil {
-		depChannel <- err
-	}
-	myAbi, err := abi.JSON(strings.NewReader(store.ContractsABI))
-	if err != nil {
-		depChannel <- err
-	}
-	for i := header.Number.Int64(); i >= 0; i-- {
-		block, err := b.client.BlockByNumber(context.Background(), big.NewInt(i))
-		if err != nil {
-			depChannel <- err
-		}
-		for _, tx := range block.Transactions() {
-			//if tx.To() == nil => Contract creation
-			if tx.To() != nil && (*(tx.To()) == b.oriAddr) {
-				txReceipt, err := b.client.TransactionReceipt(context.Background(), tx.Hash())
-				if err != nil {
-					depChannel <- err
-				}
-				//only proceed if the transaction was not reverted => valid == 1
-				if txReceipt.Status == 1 {
-					inputData := tx.Data()
-					sigdata, _ := inputData[:4], inputData[4:]
-					method, err := myAbi.MethodById(sigdata)
-					if err != nil {
-						depChannel <- err
-					}
-					if method.Name == "deposit" {
-						msg, err := tx.AsMessage(types.NewEIP155Signer(tx.ChainId()))
-						if err != nil {
-							log.Fatal(err)
-						}
-						depChannel <- optimisticrp.Deposit{msg.From(), tx.Value()}
-					} else if method.Name == "newBatch" {
-						depChannel <- err
-					}
-				}
-			}
-		}
-	}
-}
-
 //If gasPrice == -1 => ask to the client suggested gas price
 func (b *Bridge) PrepareTxOptions(value, gasLimit, gasPrice *big.Int, privKey *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
 	var err error
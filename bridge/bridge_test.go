@@ -0,0 +1,276 @@
+package bridge_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/bridge"
+	store "github.com/rogercoll/optimisticrp/contracts"
+	"github.com/rogercoll/optimisticrp/simchain"
+	"github.com/sirupsen/logrus"
+)
+
+type testChain struct {
+	*simchain.Chain
+	key *ecdsa.PrivateKey
+}
+
+func newTestChain(t *testing.T, confirmations uint64) *testChain {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := simchain.New(confirmations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Fund(crypto.PubkeyToAddress(key.PublicKey), new(big.Int).Lsh(big.NewInt(1), 80)); err != nil {
+		t.Fatal(err)
+	}
+	return &testChain{chain, key}
+}
+
+func (c *testChain) sender() common.Address {
+	return crypto.PubkeyToAddress(c.key.PublicKey)
+}
+
+func (c *testChain) call(t *testing.T, method string, args []interface{}, event string, topics []common.Hash, data ...interface{}) {
+	if _, err := c.Emit(method, args, event, topics, data...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *testChain) deposit(t *testing.T, value int64) {
+	c.call(t, "deposit", nil, "New_Deposit", nil, c.sender(), common.Hash{}, big.NewInt(value))
+}
+
+func (c *testChain) newBatch(t *testing.T, prev, root common.Hash) {
+	enc, err := optimisticrp.EncodeBatch(&optimisticrp.Batch{PrevStateRoot: prev, StateRoot: root}, optimisticrp.CompactBatchVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.call(t, "newBatch", []interface{}{enc}, "New_Batch", []common.Hash{c.sender().Hash()}, prev, root)
+}
+
+func readEvents(t *testing.T, stream *optimisticrp.EventStream) []optimisticrp.Event {
+	var events []optimisticrp.Event
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestGetOnChainData(t *testing.T) {
+	c := newTestChain(t, 0)
+	token := common.HexToAddress("0x0000000000000000000000000000000000000a11")
+	root := common.HexToHash("0x01")
+	c.deposit(t, 5)
+	c.Commit()
+	c.newBatch(t, common.Hash{}, root)
+	c.call(t, "depositToken", []interface{}{token, big.NewInt(7)}, "New_Token_Deposit", []common.Hash{c.sender().Hash(), token.Hash()}, root, big.NewInt(7))
+	c.Commit()
+	c.Commit()
+	//a page per block, the last one has no events
+	c.SetPageSize(1)
+	events := readEvents(t, optimisticrp.StreamOnChainData(context.Background(), c, 0, 3))
+	if len(events) != 6 {
+		t.Fatalf("Read %d events; want 6", len(events))
+	}
+	if deposit, ok := events[0].(optimisticrp.DepositEvent); !ok || deposit.BlockNumber != 1 || deposit.Deposit.Value.Int64() != 5 || deposit.Deposit.From != c.sender() {
+		t.Errorf("First event = %+v; want a deposit of 5 in block 1", events[0])
+	}
+	if block, ok := events[1].(optimisticrp.L1Block); !ok || block.Number != 1 {
+		t.Errorf("Second event = %+v; want block 1", events[1])
+	}
+	batch, ok := events[2].(optimisticrp.BatchEvent)
+	if !ok {
+		t.Fatalf("Third event = %+v; want a batch", events[2])
+	}
+	if goBatch, err := batch.Batch.ToGolangFormat(); err != nil || goBatch.StateRoot != root || batch.Batch.Submitter != c.sender() || batch.TxHash == (common.Hash{}) {
+		t.Errorf("Batch = %+v; want state root %v submitted by %v", batch, root, c.sender())
+	}
+	if deposit, ok := events[3].(optimisticrp.DepositEvent); !ok || deposit.Deposit.Token != token || deposit.LogIndex != 1 {
+		t.Errorf("Fourth event = %+v; want a token deposit with log index 1", events[3])
+	}
+	if block, ok := events[5].(optimisticrp.L1Block); !ok || block.Number != 3 || block.ParentHash != events[4].(optimisticrp.L1Block).Hash {
+		t.Errorf("Last event = %+v; want block 3 child of block 2", events[5])
+	}
+	c.SetStartBlock(2)
+	if events := readEvents(t, optimisticrp.StreamOnChainData(context.Background(), c, 0, 3)); len(events) != 4 {
+		t.Errorf("Read %d events from the start block; want 4", len(events))
+	}
+}
+
+func TestGetPendingDeposits(t *testing.T) {
+	c := newTestChain(t, 0)
+	c.deposit(t, 1)
+	c.newBatch(t, common.Hash{}, common.HexToHash("0x01"))
+	c.deposit(t, 2)
+	c.Commit()
+	c.deposit(t, 3)
+	c.Commit()
+	events := readEvents(t, optimisticrp.NewEventStream(context.Background(), c.GetPendingDeposits))
+	if len(events) != 2 || events[0].(optimisticrp.DepositEvent).Deposit.Value.Int64() != 2 || events[1].(optimisticrp.DepositEvent).Deposit.Value.Int64() != 3 {
+		t.Errorf("Pending deposits = %+v; want 2 and 3", events)
+	}
+}
+
+func TestSimulatedBackend(t *testing.T) {
+	c := newTestChain(t, 0)
+	opts, err := c.Bridge.PrepareTxOptions(big.NewInt(1), nil, big.NewInt(-1), c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Bridge.Deposit(opts); err != nil {
+		t.Fatal(err)
+	}
+	c.Commit()
+	c.Commit()
+	c.SetConfirmations(1)
+	number, hash, err := c.ConfirmedHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockHash, _ := c.BlockHash(1); number != 1 || hash != blockHash {
+		t.Errorf("ConfirmedHead = %d %v; want 1 %v", number, hash, blockHash)
+	}
+	if root, err := c.Bridge.GetStateRoot(); err != nil || root != (common.Hash{}) {
+		t.Errorf("GetStateRoot = %v, %v; want the empty root", root, err)
+	}
+	if chainID, err := c.ChainID(); err != nil || chainID.Cmp(big.NewInt(1337)) != 0 {
+		t.Errorf("ChainID = %v, %v; want 1337", chainID, err)
+	}
+}
+
+func TestBatchEncoding(t *testing.T) {
+	c := newTestChain(t, 0)
+	contractAbi, err := abi.JSON(strings.NewReader(store.ContractsERC20ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := c.Bridge.PrepareTxOptions(big.NewInt(0), nil, big.NewInt(1), c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := optimisticrp.Batch{StateRoot: common.HexToHash("0x01")}
+	tx, err := c.Bridge.NewBatch(batch.SolidityFormat(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := contractAbi.Methods["newBatch"].Inputs.UnpackValues(tx.Data()[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	//prove_fraud parses the legacy RLP list
+	if enc := data[0].([]byte); len(enc) == 0 || enc[0] < 0xc0 {
+		t.Errorf("Batch calldata = %x; want the legacy RLP list by default", enc)
+	}
+	c.call(t, "newBatch", []interface{}{[]byte{0xff}}, "New_Batch", []common.Hash{c.sender().Hash()}, common.Hash{}, batch.StateRoot)
+	c.Commit()
+	stream := optimisticrp.StreamOnChainData(context.Background(), c, 0, 1)
+	for stream.Next() {
+		if _, ok := stream.Event().(optimisticrp.BatchEvent); ok {
+			t.Errorf("Undecodable batch read as %+v", stream.Event())
+		}
+	}
+	if stream.Err() == nil {
+		t.Error("An undecodable accepted batch must stop the stream")
+	}
+}
+
+func TestReorg(t *testing.T) {
+	c := newTestChain(t, 0)
+	c.Commit()
+	c.deposit(t, 1)
+	c.Commit()
+	old, err := c.BlockHash(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reorg(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, optimisticrp.StreamOnChainData(context.Background(), c, 2, 3))
+	if len(events) != 1 {
+		t.Fatalf("Read %+v after the reorg; want only the last block", events)
+	}
+	if hash, _ := c.BlockHash(2); hash == old {
+		t.Error("Block 2 was not replaced by the reorg")
+	}
+	//the node links its checkpoint of block 2 with the parent hash of block 3
+	if block := events[0].(optimisticrp.L1Block); block.Number != 3 || block.ParentHash == old {
+		t.Errorf("Block = %+v; want block 3 not child of the old block 2 %v", block, old)
+	}
+	if events := readEvents(t, optimisticrp.NewEventStream(context.Background(), c.GetPendingDeposits)); len(events) != 0 {
+		t.Errorf("Pending deposits = %+v; want the deposit dropped by the reorg", events)
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	c := newTestChain(t, 1)
+	c.deposit(t, 1)
+	c.Commit()
+	c.deposit(t, 2)
+	c.Commit()
+	events := readEvents(t, optimisticrp.NewEventStream(context.Background(), c.GetPendingDeposits))
+	if len(events) != 1 || events[0].(optimisticrp.DepositEvent).Deposit.Value.Int64() != 1 {
+		t.Errorf("Pending deposits = %+v; want only the confirmed deposit of 1", events)
+	}
+	if number, _, err := c.ConfirmedHead(); err != nil || number != 1 {
+		t.Errorf("ConfirmedHead = %d, %v; want 1", number, err)
+	}
+}
+
+//removedLogs marks the logs of the given block as removed, as a node does for the logs of a block dropped by a reorg
+type removedLogs struct {
+	*backends.SimulatedBackend
+	block uint64
+}
+
+func (r removedLogs) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := r.SimulatedBackend.FilterLogs(ctx, query)
+	for i := range logs {
+		logs[i].Removed = logs[i].BlockNumber == r.block
+	}
+	return logs, err
+}
+
+func TestRemovedLogs(t *testing.T) {
+	c := newTestChain(t, 0)
+	c.deposit(t, 1)
+	c.Commit()
+	c.deposit(t, 2)
+	c.Commit()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	b, err := bridge.New(simchain.ContractAddr, removedLogs{c.Backend(), 2}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, optimisticrp.StreamOnChainData(context.Background(), b, 0, 2))
+	for _, event := range events {
+		if deposit, ok := event.(optimisticrp.DepositEvent); ok && deposit.BlockNumber == 2 {
+			t.Errorf("Read the removed deposit %+v", deposit)
+		}
+	}
+	if len(events) != 3 {
+		t.Errorf("Read %d events; want the deposit of block 1 and both blocks", len(events))
+	}
+	events = readEvents(t, optimisticrp.NewEventStream(context.Background(), b.GetPendingDeposits))
+	if len(events) != 1 || events[0].(optimisticrp.DepositEvent).Deposit.Value.Int64() != 1 {
+		t.Errorf("Pending deposits = %+v; want only the deposit of 1", events)
+	}
+}
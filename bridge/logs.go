@@ -0,0 +1,247 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/sirupsen/logrus"
+)
+
+//DefaultPageSize is the number of blocks requested on each logs query, public nodes usually limit the range or the logs of a query
+const DefaultPageSize uint64 = 5000
+
+//Events that change the layer 2 state, reverted calls emit none of them
+var (
+	onChainEvents = []string{"New_Batch", "New_Deposit", "New_withdraw", "New_Withdrawal_Claim", "New_Token_Deposit", "New_Token_Withdraw"}
+	depositEvents = []string{"New_Batch", "New_Deposit", "New_Token_Deposit"}
+)
+
+//pages splits the blocks [from, to] in ranges of at most size blocks
+func pages(from, to, size uint64) [][2]uint64 {
+	var ranges [][2]uint64
+	for start := from; start <= to; start += size {
+		end := start + size - 1
+		if end > to || end < start {
+			end = to
+		}
+		ranges = append(ranges, [2]uint64{start, end})
+		if end == to {
+			break
+		}
+	}
+	return ranges
+}
+
+func (b *Bridge) filterLogs(ctx context.Context, from, to uint64, events []string) ([]types.Log, error) {
+	var topics []common.Hash
+	for _, name := range events {
+		topics = append(topics, b.contractAbi.Events[name].ID)
+	}
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{b.oriAddr},
+		Topics:    [][]common.Hash{topics},
+	}
+	logs, err := b.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	b.log.WithFields(logrus.Fields{"From": from, "To": to, "Logs": len(logs)}).Debug("Blocks range analyzed")
+	return logs, nil
+}
+
+//GetOnChainData reads the contract events of the blocks [from, to], in pages of pageSize blocks, and sends the batches, deposits and withdraws in order
+//The events of a block are followed by its optimisticrp.L1Block, which is also sent for the block to. Blocks before the start block are skipped.
+//Only the calldata of the newBatch transactions is fetched, everything else is in the events. It stops at the first error
+func (b *Bridge) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if from < b.startBlock {
+		from = b.startBlock
+	}
+	if from > to {
+		return nil
+	}
+	b.log.Debug(fmt.Sprintf("Analyzing blocks %v to %v\n", from, to))
+	var block *optimisticrp.L1Block
+	for _, page := range pages(from, to, b.pageSize) {
+		logs, err := b.filterLogs(ctx, page[0], page[1], onChainEvents)
+		if err != nil {
+			return err
+		}
+		for _, vLog := range logs {
+			if block == nil || vLog.BlockHash != block.Hash {
+				if block != nil {
+					if err := optimisticrp.SendEvent(ctx, events, *block); err != nil {
+						return err
+					}
+				}
+				header, err := b.client.HeaderByHash(ctx, vLog.BlockHash)
+				if err != nil {
+					return err
+				}
+				block = newL1Block(header)
+			}
+			event, err := b.parseLog(ctx, vLog)
+			if err != nil {
+				return err
+			}
+			if event == nil {
+				continue
+			}
+			if err := optimisticrp.SendEvent(ctx, events, event); err != nil {
+				return err
+			}
+		}
+	}
+	if block != nil {
+		if err := optimisticrp.SendEvent(ctx, events, *block); err != nil {
+			return err
+		}
+	}
+	if block == nil || block.Number != to {
+		header, err := b.client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+		if err != nil {
+			return err
+		}
+		if err := optimisticrp.SendEvent(ctx, events, *newL1Block(header)); err != nil {
+			return err
+		}
+	}
+	b.log.Info("All blocks analized")
+	return nil
+}
+
+func newL1Block(header *types.Header) *optimisticrp.L1Block {
+	return &optimisticrp.L1Block{Number: header.Number.Uint64(), Hash: header.Hash(), ParentHash: header.ParentHash}
+}
+
+//GetPendingDeposits sends the deposits done after the last batch, in order. Pages are read backwards from the confirmed head
+//(see SetConfirmations) until the last batch event, so the deposits of blocks that may be reorged out are not sent
+func (b *Bridge) GetPendingDeposits(ctx context.Context, events chan<- optimisticrp.Event) error {
+	head, _, err := b.ConfirmedHead()
+	if err != nil {
+		return err
+	}
+	var deposits []optimisticrp.Event
+	ranges := pages(b.startBlock, head, b.pageSize)
+	for i := len(ranges) - 1; i >= 0; i-- {
+		logs, err := b.filterLogs(ctx, ranges[i][0], ranges[i][1], depositEvents)
+		if err != nil {
+			return err
+		}
+		batchFound := false
+		for j := len(logs) - 1; j >= 0 && !batchFound; j-- {
+			if logs[j].Removed {
+				continue
+			}
+			if logs[j].Topics[0] == b.contractAbi.Events["New_Batch"].ID {
+				batchFound = true
+				continue
+			}
+			event, err := b.parseLog(ctx, logs[j])
+			if err != nil {
+				return err
+			}
+			if event != nil {
+				deposits = append(deposits, event)
+			}
+		}
+		if batchFound {
+			break
+		}
+	}
+	for i := len(deposits) - 1; i >= 0; i-- {
+		if err := optimisticrp.SendEvent(ctx, events, deposits[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//parseLog returns the layer 2 input of a contract event, nil if the event is ignored
+func (b *Bridge) parseLog(ctx context.Context, vLog types.Log) (optimisticrp.Event, error) {
+	//logs of blocks removed by a reorg
+	if vLog.Removed || len(vLog.Topics) == 0 {
+		return nil, nil
+	}
+	position := optimisticrp.EventPosition{BlockNumber: vLog.BlockNumber, BlockHash: vLog.BlockHash, TxHash: vLog.TxHash, LogIndex: vLog.Index}
+	switch vLog.Topics[0] {
+	case b.contractAbi.Events["New_Batch"].ID:
+		ev, err := b.events.ParseNewBatch(vLog)
+		if err != nil {
+			return nil, err
+		}
+		goBatch, err := b.batchCalldata(ctx, vLog.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		batch := goBatch.SolidityFormat()
+		//the batch submitter earns the transactions fees
+		batch.Submitter = ev.Submitter
+		return optimisticrp.BatchEvent{EventPosition: position, Batch: batch}, nil
+	case b.contractAbi.Events["New_Deposit"].ID:
+		ev, err := b.events.ParseNewDeposit(vLog)
+		if err != nil {
+			return nil, err
+		}
+		return optimisticrp.DepositEvent{EventPosition: position, Deposit: optimisticrp.Deposit{From: ev.User, Value: ev.Value}}, nil
+	case b.contractAbi.Events["New_withdraw"].ID:
+		ev, err := b.events.ParseNewWithdraw(vLog)
+		if err != nil {
+			return nil, err
+		}
+		return optimisticrp.WithdrawEvent{EventPosition: position, Withdraw: optimisticrp.Withdraw{From: ev.User, Value: ev.Value}}, nil
+	case b.contractAbi.Events["New_Withdrawal_Claim"].ID:
+		//the claimed funds are removed from the withdrawal receipt account
+		ev, err := b.events.ParseNewWithdrawalClaim(vLog)
+		if err != nil {
+			return nil, err
+		}
+		return optimisticrp.WithdrawEvent{EventPosition: position, Withdraw: optimisticrp.Withdraw{From: ev.Receipt, Value: ev.Value}}, nil
+	case b.contractAbi.Events["New_Token_Deposit"].ID:
+		ev, err := b.events.ParseNewTokenDeposit(vLog)
+		if err != nil {
+			return nil, err
+		}
+		return optimisticrp.DepositEvent{EventPosition: position, Deposit: optimisticrp.Deposit{From: ev.User, Value: ev.Value, Token: ev.Token}}, nil
+	case b.contractAbi.Events["New_Token_Withdraw"].ID:
+		ev, err := b.events.ParseNewTokenWithdraw(vLog)
+		if err != nil {
+			return nil, err
+		}
+		return optimisticrp.WithdrawEvent{EventPosition: position, Withdraw: optimisticrp.Withdraw{From: ev.User, Value: ev.Value, Token: ev.Token}}, nil
+	}
+	return nil, nil
+}
+
+//batchCalldata fetches the transaction that emitted a New_Batch event and decodes its batch
+func (b *Bridge) batchCalldata(ctx context.Context, txHash common.Hash) (*optimisticrp.Batch, error) {
+	tx, _, err := b.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	inputData := tx.Data()
+	if len(inputData) < 4 {
+		return nil, fmt.Errorf("%s Transaction %v is not a newBatch call", optimisticrp.OPR_BANNER, txHash.Hex())
+	}
+	method, err := b.contractAbi.MethodById(inputData[:4])
+	//batches submitted through another contract would need the call trace
+	if err != nil || method.Name != "newBatch" {
+		return nil, fmt.Errorf("%s Transaction %v is not a newBatch call", optimisticrp.OPR_BANNER, txHash.Hex())
+	}
+	data, err := method.Inputs.UnpackValues(inputData[4:])
+	if err != nil {
+		return nil, err
+	}
+	//the contract accepted the batch, skipping it would leave the state without its transactions
+	goBatch, err := optimisticrp.DecodeBatch(data[0].([]byte))
+	if err != nil {
+		return nil, fmt.Errorf("%s Batch of transaction %v can not be decoded: %v", optimisticrp.OPR_BANNER, txHash.Hex(), err)
+	}
+	return goBatch, nil
+}
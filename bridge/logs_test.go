@@ -0,0 +1,24 @@
+package bridge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPages(t *testing.T) {
+	tests := []struct {
+		from, to, size uint64
+		expected       [][2]uint64
+	}{
+		{0, 0, 5000, [][2]uint64{{0, 0}}},
+		{0, 9, 5, [][2]uint64{{0, 4}, {5, 9}}},
+		{3, 10, 5, [][2]uint64{{3, 7}, {8, 10}}},
+		{10, 3, 5, nil},
+		{1, ^uint64(0), ^uint64(0), [][2]uint64{{1, ^uint64(0)}}},
+	}
+	for _, test := range tests {
+		if result := pages(test.from, test.to, test.size); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("pages(%v, %v, %v) = %v, expected %v", test.from, test.to, test.size, result, test.expected)
+		}
+	}
+}
@@ -2,13 +2,13 @@ package challenger
 
 import (
 	"crypto/ecdsa"
-	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/transition"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,7 +16,9 @@ type ChallengerNode struct {
 	accountsTrie optimisticrp.Optimistic
 	ethContract  optimisticrp.OptimisticSContract
 	privKey      *ecdsa.PrivateKey
+	sync         *transition.Syncer
 	onChainRoot  common.Hash
+	divergence   *transition.Divergence
 	log          *logrus.Entry
 }
 
@@ -24,12 +26,15 @@ func New(newAccountsTrie optimisticrp.Optimistic, newEthContract optimisticrp.Op
 	challengerLogger := logger.WithFields(logrus.Fields{
 		"service": "Challenger",
 	})
-	return &ChallengerNode{
+	v := &ChallengerNode{
 		accountsTrie: newAccountsTrie,
 		ethContract:  newEthContract,
 		privKey:      privateKey,
+		sync:         transition.NewSyncer(newAccountsTrie, newEthContract, challengerLogger),
 		log:          challengerLogger,
 	}
+	v.sync.SetReplayer(v.replay)
+	return v
 }
 
 //Sync with on-chain smart contract
@@ -42,16 +47,23 @@ func (v *ChallengerNode) Synced() (bool, error) {
 	if onChainStateRoot == v.accountsTrie.StateRoot() {
 		return true, nil
 	}
-	stateRoot, err := v.computeAccountsTrie()
+	v.divergence = nil
+	checkpoints, err := v.sync.Sync()
 	if err != nil {
 		return false, err
 	}
-	v.log.WithFields(logrus.Fields{"StateRoot": stateRoot}).Info("Computed accounts state")
+	checkpoint := checkpoints[len(checkpoints)-1]
+	v.log.WithFields(logrus.Fields{"StateRoot": checkpoint.LastRoot}).Info("Computed accounts state")
 	v.log.WithFields(logrus.Fields{"StateRoot": onChainStateRoot}).Info("OnChain accounts state")
-	if stateRoot != onChainStateRoot {
-		return false, fmt.Errorf("Aggregator was not able to compute a valid StateRoot")
+	if checkpoint.LastRoot != onChainStateRoot {
+		return false, &transition.InvalidStateRoot{Claimed: onChainStateRoot, Computed: checkpoint.LastRoot}
 	}
-	return true, nil
+	return true, v.sync.WriteCheckpoints(checkpoints)
+}
+
+//Divergence returns the first diverging step found in the last pending batch, nil if its intermediate roots were valid
+func (v *ChallengerNode) Divergence() *transition.Divergence {
+	return v.divergence
 }
 
 //Send fraud proof to the contract
@@ -98,103 +110,73 @@ func (v *ChallengerNode) VerifyOnChainData(errs chan<- interface{}) {
 	}
 }
 
-//Reads all transactions to the smart contracts and computes the whole accounts trie from scratch
-//IMPORTANT: This implementation uses the already defined OptimisticTrie object to prevent implementing the AddFunds and ProcessTx functions
-func (v *ChallengerNode) computeAccountsTrie() (common.Hash, error) {
-	optimisticTrie, ok := v.accountsTrie.(*optimisticrp.OptimisticTrie)
-	if ok != true {
-		return common.Hash{}, fmt.Errorf("This challenger implementation uses the OptimisticTrie object, if you are not, please develop your own challenger functions")
-	}
-	onChainData := make(chan interface{})
-	go v.ethContract.GetOnChainData(onChainData)
-	stateRoot := common.Hash{}
-	pendingDeposits := []optimisticrp.Deposit{}
-	pendingWithdraws := []optimisticrp.Withdraw{}
-	for methodData := range onChainData {
-		switch input := methodData.(type) {
-		case optimisticrp.SolidityBatch:
-			batch, err := input.ToGolangFormat()
-			if err != nil {
-				return stateRoot, err
-			}
-			v.log.Info("New onChain Batch received")
-			//if there is a new batch we MUST update the stateRoot with the previous deposits (rule 1.)
-			isValid, err := v.ethContract.IsStateRootValid(batch.StateRoot)
-			if err != nil {
-				return stateRoot, err
-			}
-			onChainStateRoot, err := v.ethContract.GetStateRoot()
-			if err != nil {
-				return stateRoot, err
-			}
-			v.log.Trace("Updating accounts state with new deposits")
-			for _, deposit := range pendingDeposits {
-				err := optimisticTrie.AddFunds(deposit.From, deposit.Value)
-				if err != nil {
-					return stateRoot, err
-				}
-			}
-			pendingDeposits = nil
-			v.log.Trace("Updating accounts state with last withdraws")
-			for _, withdraw := range pendingWithdraws {
-				err := optimisticTrie.RemoveFunds(withdraw.From, withdraw.Value)
-				if err != nil {
-					return stateRoot, err
-				}
-			}
-			pendingWithdraws = nil
-			if isValid {
-				v.log.Info("Updating accounts state as the provided batch is valid, it shall not contain any error")
-				for _, txInBatch := range batch.Transactions {
-					stateRoot, err = optimisticTrie.ProcessTx(txInBatch)
-					if err != nil {
-						return stateRoot, err
-					}
-				}
-				//_ = v.sendFraudProof(common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db1E"))
-			} else if !isValid && input.StateRoot == onChainStateRoot {
-				tmpTrie, err := optimisticTrie.Copy()
-				if err != nil {
-					return stateRoot, err
-				}
-				for _, txInBatch := range batch.Transactions {
-					_, err := tmpTrie.ProcessTx(txInBatch)
-					if err != nil {
-						switch fraudAccount := err.(type) {
-						case nil:
-						case *optimisticrp.InvalidBalance:
-							v.log.WithFields(logrus.Fields{"fraudAccount": fraudAccount.Addr}).Warn("Fraud found! Generating fraud proof...")
-							err := v.sendFraudProof(fraudAccount.Addr, input)
-							return stateRoot, err
-						default:
-							return stateRoot, err
-						}
-					}
-				}
-				//If after analyzing all transactions with the temporal Trie we don't get any error we can proceed updating the main Trie
-				v.log.Info("Last batch is valid but lock time has not expired, updating accounts state...")
-				for _, txInBatch := range batch.Transactions {
-					stateRoot, err = optimisticTrie.ProcessTx(txInBatch)
-					if err != nil {
-						return stateRoot, err
-					}
-				}
-			} else {
-				v.log.Debug("Skipping invalid onChain batch")
-			}
-		case optimisticrp.Deposit:
-			v.log.WithFields(logrus.Fields{"Account": input.From, "Value": input.Value}).Info("New onChain deposit")
-			pendingDeposits = append(pendingDeposits, input)
-		case optimisticrp.Withdraw:
-			v.log.WithFields(logrus.Fields{"Account": input.From, "Value": input.Value}).Info("New onChain withdraw")
-			pendingWithdraws = append(pendingWithdraws, input)
-		case error:
-			return stateRoot, input
-
-		default:
-			return common.Hash{}, errors.New("There was an error while fetching onChain data")
+//replay applies a batch read by the sync looking for frauds: the diverging step of its intermediate roots, the invalid transactions and
+//the state root of the pending batch. The provable frauds are sent to the contract and stop the sync until the batch is reverted
+func (v *ChallengerNode) replay(input optimisticrp.BatchEvent, batch optimisticrp.Batch, status transition.Status, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw) (common.Hash, []transition.Receipt, error) {
+	signer, err := v.sync.Signer()
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	if status == transition.Pending && len(batch.IntermediateRoots) > 0 {
+		divergence, err := transition.FindDivergence(v.accountsTrie, signer, deposits, withdraws, batch)
+		if err != nil {
+			return common.Hash{}, nil, err
+		}
+		if divergence != nil {
+			v.log.WithFields(logrus.Fields{"Step": divergence.Step, "From": divergence.From, "To": divergence.To, "PreStateRoot": divergence.PreStateRoot, "Claimed": divergence.Claimed, "Computed": divergence.Computed}).Warn("Fraud found! Diverging batch step")
+			v.divergence = divergence
+		}
+	}
+	root, receipts, err := transition.Replay(v.accountsTrie, signer, deposits, withdraws, batch, status)
+	switch fraudAccount := err.(type) {
+	case nil:
+	case *optimisticrp.InvalidBalance:
+		//only the provable frauds of pending batches are returned by Replay
+		v.log.WithFields(logrus.Fields{"fraudAccount": fraudAccount.Addr}).Warn("Fraud found! Generating fraud proof...")
+		if err := v.sendFraudProof(fraudAccount.Addr, input.Batch); err != nil {
+			return common.Hash{}, nil, err
+		}
+		//not synced until the fraud proof reverts the batch
+		return common.Hash{}, nil, fraudAccount
+	default:
+		return common.Hash{}, nil, err
+	}
+	for _, receipt := range receipts {
+		if receipt.Err != nil {
+			v.reportSkipped(receipt, status)
 		}
 	}
-	v.log.Info("Finished analyzing onChian data")
-	return stateRoot, nil
+	//only balances can be proven on-chain, the diverging step is kept to be reported
+	if status == transition.Pending && root != batch.StateRoot {
+		if v.divergence != nil {
+			return common.Hash{}, nil, v.divergence
+		}
+		return common.Hash{}, nil, &transition.InvalidStateRoot{Claimed: batch.StateRoot, Computed: root}
+	}
+	if status == transition.Valid && root != batch.StateRoot {
+		v.log.WithFields(logrus.Fields{"Claimed": batch.StateRoot, "Computed": root, "Status": status}).Warn("Batch state root differs from the computed one")
+	}
+	return root, receipts, nil
+}
+
+//Reports an invalid transaction that can not be proven on-chain, Replay skipped it. It is not a fraud of the batch (rule 4.), a badly
+//signed transaction included by the aggregator does not change the state
+func (v *ChallengerNode) reportSkipped(receipt transition.Receipt, status transition.Status) {
+	log := v.log.WithFields(logrus.Fields{"Index": receipt.Index, "fraudAccount": receipt.From, "Status": status})
+	switch fraud := receipt.Err.(type) {
+	case *optimisticrp.InvalidBalance:
+		//the contract fraud proof only replays ether balances
+		log.WithFields(logrus.Fields{"Token": fraud.Token}).Warn("Fraud found! Token balance can not be proven on-chain, transaction skipped")
+	case *optimisticrp.InvalidSignature:
+		log.Warn("Fraud found! Transaction with an invalid signature skipped")
+	case *optimisticrp.InvalidNonce:
+		//replayed or reordered transaction
+		log.WithFields(logrus.Fields{"Expected": fraud.Expected, "Got": fraud.Got}).Warn("Fraud found! Transaction with an invalid nonce skipped")
+	case *optimisticrp.InvalidMultiSend:
+		log.WithFields(logrus.Fields{"Reason": fraud.Reason}).Warn("Fraud found! Malformed multi-send transaction skipped")
+	case *optimisticrp.UnknownTxType:
+		log.WithFields(logrus.Fields{"Type": fraud.Type}).Warn("Fraud found! Transaction of an unknown type skipped")
+	default:
+		log.WithFields(logrus.Fields{"Error": receipt.Err}).Warn("Fraud found! Invalid transaction skipped")
+	}
 }
@@ -1,6 +1,7 @@
 package challenger
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"log"
 	"math/big"
@@ -9,10 +10,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/transition"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,11 +23,23 @@ var addrAccount1 = common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db
 var addrAccount2 = common.HexToAddress("0x9185eAE1c5AD845137AaDf34a955e1D676fE421B")
 var addrAccount3 = common.HexToAddress("0x522fE0423db9de4e8Bb88aF3bF24aBE9B7dBF787")
 var account1 = optimisticrp.Account{Balance: new(big.Int).SetUint64(0), Nonce: 0}
+var privAccount1, _ = crypto.HexToECDSA("ff10aa6af851c1b49b7d3a94611d7823adbcfae76e153fc2757b4108a1dc402d")
+var privAccount2, _ = crypto.HexToECDSA("482254ce62c1473ccbf354bf33e08d71ff09dd2859e4fb8ae08d228fb8b727a5")
+
+func signTx(tx optimisticrp.Transaction, privKey *ecdsa.PrivateKey) optimisticrp.Transaction {
+	if tx.Gas == nil {
+		tx.Gas = big.NewInt(0)
+	}
+	signedTx, err := optimisticrp.SignTx(&tx, optimisticrp.NewRollupSigner(big.NewInt(1337), common.Address{}), privKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return *signedTx
+}
 
 type mockBridge struct {
 }
 
-func (m *mockBridge) Client() *ethclient.Client          { return nil }
 func (m *mockBridge) GetStateRoot() (common.Hash, error) { return common.Hash{}, nil }
 func (m *mockBridge) NewBatch(optimisticrp.SolidityBatch, *bind.TransactOpts) (*types.Transaction, error) {
 	return nil, nil
@@ -38,44 +52,55 @@ func (m *mockBridge) Withdraw(*bind.TransactOpts, []byte, []byte, []byte, []byte
 }
 func (m *mockBridge) Deposit(*bind.TransactOpts) (*types.Transaction, error) { return nil, nil }
 func (m *mockBridge) Bond(*bind.TransactOpts) (*types.Transaction, error)    { return nil, nil }
+func (m *mockBridge) ChainID() (*big.Int, error)                             { return big.NewInt(1337), nil }
 func (m *mockBridge) OriAddr() common.Address                                { return common.Address{} }
-func (m *mockBridge) GetPendingDeposits(depChannel chan<- interface{}) {
-	defer close(depChannel)
-	depChannel <- optimisticrp.Deposit{addrAccount2, big.NewInt(1e+18)}
+func (m *mockBridge) GetPendingDeposits(ctx context.Context, events chan<- optimisticrp.Event) error {
+	return optimisticrp.SendEvent(ctx, events, optimisticrp.DepositEvent{Deposit: optimisticrp.Deposit{From: addrAccount2, Value: big.NewInt(1e+18)}})
 }
 
-func (m *mockBridge) IsStateRootValid(common.Hash) (bool, error) {
-	return true, nil
+//validRoot is claimed by the first batch, accepted once the second one was submitted
+var validRoot = common.HexToHash("0x0b1")
+
+//The second batch claims the on-chain state root, so it is pending and its fraud can be proven
+func (m *mockBridge) IsStateRootValid(root common.Hash) (bool, error) {
+	return root == validRoot, nil
 }
 
 func (m *mockBridge) PrepareTxOptions(*big.Int, *big.Int, *big.Int, *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
 	return nil, nil
 }
-func (m *mockBridge) GetOnChainData(txChannel chan<- interface{}) {
-	defer close(txChannel)
-	txs := []optimisticrp.Transaction{
-		{
-			From:  addrAccount1,
-			To:    addrAccount2,
-			Value: big.NewInt(1e+18),
-		},
-		{
-			From:  addrAccount1,
-			To:    addrAccount3,
-			Value: big.NewInt(1e+18),
-		},
+func (m *mockBridge) ConfirmedHead() (uint64, common.Hash, error) {
+	return 1, common.HexToHash("0x01"), nil
+}
+func (m *mockBridge) BlockHash(uint64) (common.Hash, error) { return common.HexToHash("0x01"), nil }
+
+//All the on-chain data is in block 1
+func (m *mockBridge) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if from > 1 || to < 1 {
+		return nil
 	}
-	txs2 := []optimisticrp.Transaction{
-		{
-			From:  addrAccount2,
-			To:    addrAccount1,
-			Value: big.NewInt(3e+18),
-		},
+	batch := optimisticrp.Batch{StateRoot: validRoot, Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1e+18)}, privAccount1),
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount3, Value: big.NewInt(1e+18), Nonce: 1}, privAccount1),
+	}}
+	batch2 := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount2, To: addrAccount1, Value: big.NewInt(3e+18)}, privAccount2),
+	}}
+	at := func(index uint) optimisticrp.EventPosition {
+		return optimisticrp.EventPosition{BlockNumber: 1, BlockHash: common.HexToHash("0x01"), LogIndex: index}
 	}
-	txChannel <- optimisticrp.Deposit{addrAccount1, big.NewInt(0).SetUint64(10e+18)}
-	txChannel <- optimisticrp.Batch{Transactions: txs}
-	txChannel <- optimisticrp.Deposit{addrAccount3, big.NewInt(0).SetUint64(8e+18)}
-	txChannel <- optimisticrp.Batch{Transactions: txs2}
+	for _, event := range []optimisticrp.Event{
+		optimisticrp.DepositEvent{EventPosition: at(0), Deposit: optimisticrp.Deposit{From: addrAccount1, Value: big.NewInt(0).SetUint64(10e+18)}},
+		optimisticrp.BatchEvent{EventPosition: at(1), Batch: batch.SolidityFormat()},
+		optimisticrp.DepositEvent{EventPosition: at(2), Deposit: optimisticrp.Deposit{From: addrAccount3, Value: big.NewInt(0).SetUint64(8e+18)}},
+		optimisticrp.BatchEvent{EventPosition: at(3), Batch: batch2.SolidityFormat()},
+		optimisticrp.L1Block{Number: 1, Hash: common.HexToHash("0x01")},
+	} {
+		if err := optimisticrp.SendEvent(ctx, events, event); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 func TestMain(m *testing.M) {
 	var (
@@ -114,3 +139,43 @@ func TestComputeAccountsTrie(t *testing.T) {
 		}
 	}
 }
+
+//divergingBridge submits a pending batch whose only transaction is badly signed, the batch claims the on-chain state root
+type divergingBridge struct {
+	mockBridge
+}
+
+func (m *divergingBridge) GetOnChainData(ctx context.Context, from, to uint64, events chan<- optimisticrp.Event) error {
+	if from > 1 || to < 1 {
+		return nil
+	}
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1e+18)}, privAccount2),
+	}}
+	at := optimisticrp.EventPosition{BlockNumber: 1, BlockHash: common.HexToHash("0x01")}
+	for _, event := range []optimisticrp.Event{
+		optimisticrp.DepositEvent{EventPosition: at, Deposit: optimisticrp.Deposit{From: addrAccount1, Value: big.NewInt(1e+18)}},
+		optimisticrp.BatchEvent{EventPosition: optimisticrp.EventPosition{BlockNumber: 1, BlockHash: at.BlockHash, LogIndex: 1}, Batch: batch.SolidityFormat()},
+		optimisticrp.L1Block{Number: 1, Hash: at.BlockHash},
+	} {
+		if err := optimisticrp.SendEvent(ctx, events, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSyncedDivergingRoot(t *testing.T) {
+	tr, err := optimisticrp.NewTrie(trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := New(tr, &divergingBridge{}, nil, logger)
+	//the badly signed transaction is skipped, not proven, the deposit alone does not give the claimed root
+	_, err = node.Synced()
+	if fraud, ok := err.(*transition.InvalidStateRoot); !ok || fraud.Claimed != (common.Hash{}) {
+		t.Fatalf("Synced = %v; want InvalidStateRoot of the pending batch", err)
+	}
+}
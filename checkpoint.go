@@ -0,0 +1,110 @@
+package optimisticrp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//Checkpoints are stored under checkpointPrefix + block number (uint64 big endian)
+var checkpointPrefix = []byte("Checkpoint-")
+
+//MaxCheckpoints is the number of checkpoints kept, a reorg deeper than the oldest one needs a full resync
+const MaxCheckpoints = 128
+
+//Checkpoint is a layer 1 block processed by a node, the on-chain data ingestion is resumed from the next one
+//The deposits and withdraws found after the last batch are kept until a batch applies them
+type Checkpoint struct {
+	Block     uint64
+	BlockHash common.Hash
+	StateRoot common.Hash //committed accounts state after the block
+	Batch     uint64      //number of batches processed
+	LastRoot  common.Hash //state root of the last accepted batch, the on-chain one when the node is synced
+	Deposits  []Deposit
+	Withdraws []Withdraw
+}
+
+type InvalidCheckpoint struct {
+	Block  uint64
+	Reason string
+}
+
+func (e *InvalidCheckpoint) Error() string {
+	return fmt.Sprintf("%s Invalid checkpoint at block %d: %s", OPR_BANNER, e.Block, e.Reason)
+}
+
+//Reorg is found when the blocks read are not linked by their parent hashes, the chain changed while it was read
+type Reorg struct {
+	Block uint64
+}
+
+func (e *Reorg) Error() string {
+	return fmt.Sprintf("%s Layer 1 reorg detected at block %d", OPR_BANNER, e.Block)
+}
+
+//readCheckpoints returns the stored checkpoints from the oldest to the newest block
+func readCheckpoints(diskdb ethdb.KeyValueStore) ([]*Checkpoint, error) {
+	var checkpoints []*Checkpoint
+	it := diskdb.NewIterator(checkpointPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		//trie nodes are keyed by their hash, skip any that shares the prefix
+		if len(it.Key()) != len(checkpointPrefix)+8 {
+			continue
+		}
+		var checkpoint Checkpoint
+		if err := rlp.DecodeBytes(it.Value(), &checkpoint); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, &checkpoint)
+	}
+	return checkpoints, it.Error()
+}
+
+//readCheckpoint returns the newest checkpoint, nil if none was written yet
+func readCheckpoint(diskdb ethdb.KeyValueStore) (*Checkpoint, error) {
+	checkpoints, err := readCheckpoints(diskdb)
+	if err != nil || len(checkpoints) == 0 {
+		return nil, err
+	}
+	return checkpoints[len(checkpoints)-1], nil
+}
+
+//writeCheckpoint stores the newest checkpoint, the ones of later blocks belong to a replaced chain and are deleted with the oldest ones above MaxCheckpoints
+func writeCheckpoint(diskdb ethdb.KeyValueStore, checkpoint *Checkpoint) error {
+	checkpoints, err := readCheckpoints(diskdb)
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, old := range checkpoints {
+		if old.Block > checkpoint.Block {
+			if err := diskdb.Delete(checkpointKey(old.Block)); err != nil {
+				return err
+			}
+		} else if old.Block < checkpoint.Block {
+			kept++
+		}
+	}
+	for i := 0; kept >= MaxCheckpoints; i++ {
+		if err := diskdb.Delete(checkpointKey(checkpoints[i].Block)); err != nil {
+			return err
+		}
+		kept--
+	}
+	enc, err := rlp.EncodeToBytes(checkpoint)
+	if err != nil {
+		return err
+	}
+	return diskdb.Put(checkpointKey(checkpoint.Block), enc)
+}
+
+func checkpointKey(block uint64) []byte {
+	key := make([]byte, len(checkpointPrefix)+8)
+	copy(key, checkpointPrefix)
+	binary.BigEndian.PutUint64(key[len(checkpointPrefix):], block)
+	return key
+}
@@ -2,8 +2,7 @@ package client
 
 import (
 	"crypto/ecdsa"
-	"crypto/sha256"
-	"fmt"
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -11,13 +10,18 @@ import (
 	"github.com/rogercoll/optimisticrp"
 )
 
+//ErrNoAggregator is returned by the calls that need the aggregator node of a client created without it
+var ErrNoAggregator = errors.New("client without aggregator node")
+
 type OpClient struct {
 	privKey        *ecdsa.PrivateKey
 	ethAddr        common.Address
 	aggregatorNode *optimisticrp.Aggregator
+	signer         optimisticrp.Signer
 }
 
-func New(hexPrivKey string, aggregator *optimisticrp.Aggregator) (*OpClient, error) {
+//New returns a client signing transactions with the given signer, use optimisticrp.ContractSigner to get the rollup one
+func New(hexPrivKey string, signer optimisticrp.Signer, aggregator *optimisticrp.Aggregator) (*OpClient, error) {
 	privateKey, err := crypto.HexToECDSA(hexPrivKey)
 	if err != nil {
 		return nil, err
@@ -27,11 +31,14 @@ func New(hexPrivKey string, aggregator *optimisticrp.Aggregator) (*OpClient, err
 	if !ok {
 		return nil, err
 	}
-	return &OpClient{privateKey, crypto.PubkeyToAddress(*publicKeyECDSA), aggregator}, nil
+	return &OpClient{privateKey, crypto.PubkeyToAddress(*publicKeyECDSA), aggregator, signer}, nil
 }
 
 func (client *OpClient) NewTx(from, to common.Address, value, gas *big.Int) (*optimisticrp.Transaction, error) {
 	agg := client.aggregatorNode
+	if agg == nil {
+		return nil, ErrNoAggregator
+	}
 	fnonce := (*agg).ActualNonce(from)
 	tx := optimisticrp.Transaction{
 		From:  from,
@@ -43,32 +50,39 @@ func (client *OpClient) NewTx(from, to common.Address, value, gas *big.Int) (*op
 	return &tx, nil
 }
 
-func (client *OpClient) SignTx(tx *optimisticrp.Transaction) (*optimisticrp.Transaction, error) {
-	h := client.Hash(tx)
-	sig, err := crypto.Sign(h[:], client.privKey)
+//NewTokenTx builds a transfer of value units of the given ERC20 token, the fee is paid in weis
+func (client *OpClient) NewTokenTx(from, to, token common.Address, value, gas *big.Int) (*optimisticrp.Transaction, error) {
+	tx, err := client.NewTx(from, to, value, gas)
 	if err != nil {
 		return nil, err
 	}
-	return tx.WithSignature(client, sig)
+	tx.Token = token
+	return tx, nil
 }
 
-func (client *OpClient) Hash(tx *optimisticrp.Transaction) common.Hash {
-	h := sha256.New()
-	h.Write([]byte(fmt.Sprintf("%v", tx)))
-	return common.BytesToHash(h.Sum(nil))
+//NewWithdrawalTx builds a withdrawal of value units of asset (optimisticrp.Ether or an ERC20 token), once its batch is valid the
+//amount is claimed on-chain with the proof of optimisticrp.NewWithdrawalProof(state, from, tx.Nonce)
+func (client *OpClient) NewWithdrawalTx(from, asset common.Address, value, gas *big.Int) (*optimisticrp.Transaction, error) {
+	return client.NewTokenTx(from, optimisticrp.WithdrawalAddress, asset, value, gas)
 }
 
-func (client *OpClient) SignatureValues(sig []byte) (r, s, v *big.Int, err error) {
-	if len(sig) != crypto.SignatureLength {
-		return nil, nil, nil, fmt.Errorf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength)
+//NewMultiSendTx builds a single transaction paying every recipient from the sender, it is applied atomically
+func (client *OpClient) NewMultiSendTx(from, asset common.Address, payments []optimisticrp.Payment, gas *big.Int) (*optimisticrp.Transaction, error) {
+	agg := client.aggregatorNode
+	if agg == nil {
+		return nil, ErrNoAggregator
 	}
-	r = new(big.Int).SetBytes(sig[:32])
-	s = new(big.Int).SetBytes(sig[32:64])
-	v = new(big.Int).SetBytes([]byte{sig[64] + 27})
-	return r, s, v, nil
+	return optimisticrp.NewMultiSendTx(from, asset, payments, gas, (*agg).ActualNonce(from))
+}
+
+func (client *OpClient) SignTx(tx *optimisticrp.Transaction) (*optimisticrp.Transaction, error) {
+	return optimisticrp.SignTx(tx, client.signer, client.privKey)
 }
 
 func (client *OpClient) SendTx(tx *optimisticrp.Transaction) error {
 	agg := client.aggregatorNode
+	if agg == nil {
+		return ErrNoAggregator
+	}
 	return (*agg).ReceiveTransaction(*tx)
 }
@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/rogercoll/optimisticrp"
 )
 
@@ -15,12 +16,14 @@ var (
 	priv5 = "6be7af0159b0f06c078c583df4f262bffc946dbc50c550667225adf1e27b365e"
 )
 
+var signer = optimisticrp.NewRollupSigner(big.NewInt(1337), common.HexToAddress("0x8A5a6C65B99b019f2b23e2Fad8CbD46dDAddFbDA"))
+
 func TestSignTx(t *testing.T) {
-	client1, err := New(priv1, nil)
+	client1, err := New(priv1, signer, nil)
 	if err != nil {
 		t.Error(err)
 	}
-	client2, err := New(priv2, nil)
+	client2, err := New(priv2, signer, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -43,3 +46,79 @@ func TestSignTx(t *testing.T) {
 		t.Errorf("Signed transaction from two different clients must be different")
 	}
 }
+
+func TestSender(t *testing.T) {
+	client1, err := New(priv1, signer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client2, err := New(priv2, signer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opTx := optimisticrp.Transaction{
+		From:  client1.ethAddr,
+		To:    client2.ethAddr,
+		Value: big.NewInt(1e+18),
+		Gas:   big.NewInt(0),
+	}
+	signedTx, err := client1.SignTx(&opTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := optimisticrp.VerifySender(client1.signer, signedTx); err != nil {
+		t.Error(err)
+	}
+	forgedTx, err := client2.SignTx(&opTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := optimisticrp.VerifySender(client1.signer, forgedTx).(*optimisticrp.InvalidSignature); !ok {
+		t.Errorf("Transaction signed by %v accepted as sent by %v", client2.ethAddr, client1.ethAddr)
+	}
+	//V + 256 truncates to the same recovery id, it would give the signed transaction another hash
+	malleable := *signedTx
+	malleable.V = new(big.Int).Add(signedTx.V, big.NewInt(256))
+	if _, err := optimisticrp.Sender(client1.signer, &malleable); err != optimisticrp.ErrInvalidSig {
+		t.Errorf("Sender of V = %v: %v; want ErrInvalidSig", malleable.V, err)
+	}
+}
+
+func TestSignatureDomain(t *testing.T) {
+	client1, err := New(priv1, signer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opTx := optimisticrp.Transaction{
+		From:  client1.ethAddr,
+		Value: big.NewInt(1e+18),
+		Gas:   big.NewInt(0),
+	}
+	signedTx, err := client1.SignTx(&opTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherChain := optimisticrp.NewRollupSigner(big.NewInt(1), signer.Contract())
+	otherContract := optimisticrp.NewRollupSigner(signer.ChainID(), common.HexToAddress(priv1[:40]))
+	for _, other := range []optimisticrp.Signer{otherChain, otherContract} {
+		if err := optimisticrp.VerifySender(other, signedTx); err == nil {
+			t.Errorf("Signature replayed on another rollup deployment")
+		}
+	}
+}
+
+func TestNoAggregator(t *testing.T) {
+	client1, err := New(priv1, signer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client1.NewTx(client1.ethAddr, common.Address{1}, big.NewInt(1), big.NewInt(0)); err != ErrNoAggregator {
+		t.Errorf("NewTx error = %v; want ErrNoAggregator", err)
+	}
+	if _, err := client1.NewMultiSendTx(client1.ethAddr, optimisticrp.Ether, []optimisticrp.Payment{{To: common.Address{1}, Value: big.NewInt(1)}}, big.NewInt(0)); err != ErrNoAggregator {
+		t.Errorf("NewMultiSendTx error = %v; want ErrNoAggregator", err)
+	}
+	if err := client1.SendTx(&optimisticrp.Transaction{}); err != ErrNoAggregator {
+		t.Errorf("SendTx error = %v; want ErrNoAggregator", err)
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
 	"log"
 	"math/big"
 	"os"
@@ -9,8 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/ethdb/memorydb"
-	"github.com/ethereum/go-ethereum/trie"
 	"github.com/rogercoll/optimisticrp"
 	"github.com/rogercoll/optimisticrp/aggregator"
 	"github.com/rogercoll/optimisticrp/bridge"
@@ -34,7 +35,17 @@ func randomAddress() common.Address {
 	return common.HexToAddress(crypto.PubkeyToAddress(*publicKeyECDSA).Hex())
 }
 
+var datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted, kept in memory if empty")
+var backend = flag.String("backend", "trie", "Accounts state backend: trie or smt (smt proofs can not be verified on-chain)")
+var startBlock = flag.Uint64("startblock", 0, "First block read from the contract events, usually its deployment block")
+var pageSize = flag.Uint64("pagesize", bridge.DefaultPageSize, "Number of blocks requested on each contract events query")
+var confirmations = flag.Uint64("confirmations", 0, "Blocks mined on top of a block before its contract events are read")
+var rootInterval = flag.Int("roots", 0, "Record the state root every k transactions of the batch, 0 disables intermediate roots")
+var multiSend = flag.Int("multisend", 0, "Pay this many random receivers with each transaction using multi-sends, 0 sends one transfer per receiver")
+var witnessFile = flag.String("witness", "", "File where the hex encoded witness of the sent batch is written")
+
 func main() {
+	flag.Parse()
 	var logger = logrus.New()
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.DebugLevel)
@@ -47,19 +58,20 @@ func main() {
 	if err != nil {
 		logger.Fatal(err)
 	}
-	var (
-		diskdb = memorydb.New()
-		triedb = trie.NewDatabase(diskdb)
-	)
-	tr, err := optimisticrp.NewTrie(triedb)
+	mybridge.SetStartBlock(*startBlock)
+	mybridge.SetPageSize(*pageSize)
+	mybridge.SetConfirmations(*confirmations)
+	db, tr, err := cmd.OpenAccountsState(*datadir, *backend)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	defer db.Close()
 	privateKey, err := crypto.HexToECDSA(cmd.AggregatorPriv)
 	if err != nil {
 		logger.Fatal(err)
 	}
 	myaggregator := aggregator.New(tr, mybridge, privateKey, logger)
+	myaggregator.SetRootInterval(*rootInterval)
 	syn, err := myaggregator.Synced()
 	if err != nil {
 		logger.Fatal(err)
@@ -67,20 +79,59 @@ func main() {
 		logger.Fatal("Was not able to syncronize")
 	}
 	logger.Info("Successfully syncronized with on-chain data")
+	signer, err := optimisticrp.ContractSigner(mybridge)
+	if err != nil {
+		logger.Fatal(err)
+	}
 	for i := 0; i < 1; i++ {
-		tx := optimisticrp.Transaction{Value: big.NewInt(1e+18), Gas: big.NewInt(1e+18), To: addrAccount2, From: addrAccount1}
-		err := myaggregator.ReceiveTransaction(tx)
+		nonce, err := myaggregator.ActualNonce(addrAccount1)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		tx, err := optimisticrp.SignTx(&optimisticrp.Transaction{Value: big.NewInt(1e+18), Gas: big.NewInt(1e+12), To: addrAccount2, From: addrAccount1, Nonce: nonce}, signer, privateKey)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		err = myaggregator.ReceiveTransaction(*tx)
 		if err != nil {
 			logger.Fatal(err)
 		}
 	}
 	for i := 0; i < aggregator.MAX_TRANSACTIONS_BATCH-1; i++ {
 		logger.Info("Generating random receivers address to increase the trie size")
-		tx := optimisticrp.Transaction{Value: big.NewInt(1e+14), Gas: big.NewInt(1e+18), To: randomAddress(), From: addrAccount1}
-		err := myaggregator.ReceiveTransaction(tx)
+		nonce, err := myaggregator.ActualNonce(addrAccount1)
 		if err != nil {
 			logger.Fatal(err)
 		}
+		unsigned := &optimisticrp.Transaction{Value: big.NewInt(1e+14), Gas: big.NewInt(1e+12), To: randomAddress(), From: addrAccount1, Nonce: nonce}
+		if *multiSend > 0 {
+			payments := make([]optimisticrp.Payment, *multiSend)
+			for j := range payments {
+				payments[j] = optimisticrp.Payment{To: randomAddress(), Value: big.NewInt(1e+14)}
+			}
+			unsigned, err = optimisticrp.NewMultiSendTx(addrAccount1, optimisticrp.Ether, payments, big.NewInt(1e+12), nonce)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+		tx, err := optimisticrp.SignTx(unsigned, signer, privateKey)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		err = myaggregator.ReceiveTransaction(*tx)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	if *witnessFile != "" && myaggregator.Witness() != nil {
+		enc, err := myaggregator.Witness().MarshalBinary()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		if err := ioutil.WriteFile(*witnessFile, []byte(hex.EncodeToString(enc)), 0644); err != nil {
+			logger.Fatal(err)
+		}
+		logger.WithFields(logrus.Fields{"Nodes": len(myaggregator.Witness().Nodes), "Bytes": len(enc)}).Info("Batch witness written")
 	}
 	/*
 		proof, err := tr.NewProve(addrAccount2)
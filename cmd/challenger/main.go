@@ -1,14 +1,12 @@
 package main
 
 import (
+	"flag"
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/ethdb/memorydb"
-	"github.com/ethereum/go-ethereum/trie"
-	"github.com/rogercoll/optimisticrp"
 	"github.com/rogercoll/optimisticrp/bridge"
 	"github.com/rogercoll/optimisticrp/challenger"
 	"github.com/rogercoll/optimisticrp/cmd"
@@ -18,7 +16,14 @@ import (
 var addrAccount1 = common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db1E")
 var addrAccount2 = common.HexToAddress("0x9185eAE1c5AD845137AaDf34a955e1D676fE421B")
 
+var datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted, kept in memory if empty")
+var backend = flag.String("backend", "trie", "Accounts state backend: trie or smt (smt proofs can not be verified on-chain)")
+var startBlock = flag.Uint64("startblock", 0, "First block read from the contract events, usually its deployment block")
+var pageSize = flag.Uint64("pagesize", bridge.DefaultPageSize, "Number of blocks requested on each contract events query")
+var confirmations = flag.Uint64("confirmations", 0, "Blocks mined on top of a block before its contract events are read")
+
 func main() {
+	flag.Parse()
 	var logger = logrus.New()
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.DebugLevel)
@@ -31,14 +36,14 @@ func main() {
 	if err != nil {
 		logger.Fatal(err)
 	}
-	var (
-		diskdb = memorydb.New()
-		triedb = trie.NewDatabase(diskdb)
-	)
-	tr, err := optimisticrp.NewTrie(triedb)
+	mybridge.SetStartBlock(*startBlock)
+	mybridge.SetPageSize(*pageSize)
+	mybridge.SetConfirmations(*confirmations)
+	db, tr, err := cmd.OpenAccountsState(*datadir, *backend)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	defer db.Close()
 	privateKey, err := crypto.HexToECDSA(cmd.ChallengerPriv)
 	if err != nil {
 		logger.Fatal(err)
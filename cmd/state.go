@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+)
+
+//OpenAccountsTrie opens the accounts trie persisted in datadir at its last committed root, if datadir is empty the trie is kept in memory
+func OpenAccountsTrie(datadir string) (*optimisticrp.Database, *optimisticrp.OptimisticTrie, error) {
+	db, state, err := OpenAccountsState(datadir, string(optimisticrp.TrieBackend))
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, state.(*optimisticrp.OptimisticTrie), nil
+}
+
+//OpenAccountsState opens the accounts state of the given backend (trie or smt), only the trie can be persisted in datadir
+func OpenAccountsState(datadir, backend string) (*optimisticrp.Database, optimisticrp.Optimistic, error) {
+	db := optimisticrp.NewMemoryDatabase()
+	if datadir != "" {
+		var err error
+		db, err = optimisticrp.NewLevelDB(datadir)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	state, err := db.OpenState(optimisticrp.Backend(backend))
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, state, nil
+}
+
+//OpenState opens a read-only view of the state at root, or at batch if root is empty, or at the last committed batch if both are empty
+func OpenState(db *optimisticrp.Database, batch uint64, root string) (*optimisticrp.HistoricalState, error) {
+	switch {
+	case root != "":
+		return db.StateAt(common.HexToHash(root))
+	case batch != 0:
+		return db.StateAtBatch(batch)
+	default:
+		head, err := db.Head()
+		if err != nil {
+			return nil, err
+		}
+		return db.StateAtBatch(head.Batch)
+	}
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/ethdb/memorydb"
-	"github.com/ethereum/go-ethereum/trie"
-	"github.com/rogercoll/optimisticrp"
 	"github.com/rogercoll/optimisticrp/aggregator"
 	"github.com/rogercoll/optimisticrp/bridge"
 	"github.com/rogercoll/optimisticrp/cmd"
@@ -19,7 +17,11 @@ import (
 var addrAccount1 = common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db1E")
 var addrAccount2 = common.HexToAddress("0x9185eAE1c5AD845137AaDf34a955e1D676fE421B")
 
+var datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted, kept in memory if empty")
+var backend = flag.String("backend", "trie", "Accounts state backend: trie or smt (smt proofs can not be verified on-chain)")
+
 func main() {
+	flag.Parse()
 	var logger = logrus.New()
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.DebugLevel)
@@ -32,14 +34,11 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	var (
-		diskdb = memorydb.New()
-		triedb = trie.NewDatabase(diskdb)
-	)
-	tr, err := optimisticrp.NewTrie(triedb)
+	db, tr, err := cmd.OpenAccountsState(*datadir, *backend)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer db.Close()
 	privateKey, err := crypto.HexToECDSA("6be7af0159b0f06c078c583df4f262bffc946dbc50c550667225adf1e27b365e")
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/cmd"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted")
+	account = flag.String("account", "", "Account address")
+	batch   = flag.Uint64("batch", 0, "Batch number, the last committed state if 0")
+	root    = flag.String("root", "", "State root, takes precedence over batch")
+)
+
+func main() {
+	flag.Parse()
+	var logger = logrus.New()
+	logger.SetOutput(os.Stdout)
+	db, err := optimisticrp.NewLevelDB(*datadir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	state, err := cmd.OpenState(db, *batch, *root)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	acc, err := state.GetAccount(common.HexToAddress(*account))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.WithFields(logrus.Fields{"Batch": state.Batch(), "StateRoot": state.StateRoot(), "Nonce": acc.Nonce, "Balance": acc.Balance}).Info("Account state")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/aggregator"
+	"github.com/rogercoll/optimisticrp/bridge"
+	"github.com/rogercoll/optimisticrp/cmd"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted, kept in memory if empty")
+	nonce   = flag.Uint64("nonce", 0, "Nonce of the withdrawal transaction")
+	token   = flag.String("token", "", "ERC20 token address to claim, ether if empty")
+)
+
+//Claims on-chain the funds burnt by a layer 2 withdrawal transaction of the withdrawer account
+func main() {
+	flag.Parse()
+	var logger = logrus.New()
+	logger.SetOutput(os.Stdout)
+	client, err := ethclient.Dial("http://127.0.0.1:8545")
+	if err != nil {
+		logger.Fatal(err)
+	}
+	mybridge, err := bridge.New(common.HexToAddress(cmd.ContractAddr), client, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	db, tr, err := cmd.OpenAccountsTrie(*datadir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	privateKey, err := crypto.HexToECDSA(cmd.WithdrawerPriv)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	fromAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	myaggregator := aggregator.New(tr, mybridge, privateKey, logger)
+	syn, err := myaggregator.Synced()
+	if err != nil {
+		logger.Fatal(err)
+	} else if syn == false {
+		logger.Fatal("Was not able to syncronize")
+	}
+	asset := optimisticrp.Ether
+	if *token != "" {
+		asset = common.HexToAddress(*token)
+	}
+	proof, err := optimisticrp.NewWithdrawalProof(tr, fromAddress, *nonce)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	amount, err := optimisticrp.VerifyWithdrawalProof(tr.StateRoot(), fromAddress, *nonce, asset, proof[2])
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.WithFields(logrus.Fields{"Receipt": optimisticrp.WithdrawalReceipt(fromAddress, *nonce), "Token": asset, "Amount": amount}).Warn("Claiming withdrawal")
+	txOpts, err := mybridge.PrepareTxOptions(big.NewInt(0), big.NewInt(2), big.NewInt(2), privateKey)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if asset == optimisticrp.Ether {
+		_, err = mybridge.ClaimWithdrawal(txOpts, *nonce, proof)
+	} else {
+		_, err = mybridge.ClaimTokenWithdrawal(txOpts, asset, *nonce, proof)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
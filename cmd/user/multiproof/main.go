@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/cmd"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	datadir  = flag.String("datadir", "", "Directory where the accounts trie is persisted")
+	accounts = flag.String("accounts", "", "Comma separated account addresses")
+	batch    = flag.Uint64("batch", 0, "Batch number, the last committed state if 0")
+	root     = flag.String("root", "", "State root, takes precedence over batch")
+)
+
+//Prints the hex encoded multiproof of the given accounts
+func main() {
+	flag.Parse()
+	var logger = logrus.New()
+	logger.SetOutput(os.Stderr)
+	db, err := optimisticrp.NewLevelDB(*datadir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	state, err := cmd.OpenState(db, *batch, *root)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	var addresses []common.Address
+	for _, account := range strings.Split(*accounts, ",") {
+		addresses = append(addresses, common.HexToAddress(strings.TrimSpace(account)))
+	}
+	mp, err := state.NewMultiProof(addresses)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	enc, err := mp.MarshalBinary()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.WithFields(logrus.Fields{"Batch": state.Batch(), "StateRoot": state.StateRoot(), "Accounts": len(addresses), "Nodes": len(mp.Nodes), "Bytes": len(enc)}).Info("Multiproof generated")
+	fmt.Println(hex.EncodeToString(enc))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/bridge"
+	"github.com/rogercoll/optimisticrp/cmd"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	txHash = flag.String("tx", "", "Hash of the rollup transaction")
+	batch  = flag.Uint64("batch", 0, "Batch number where the transaction is looked for, every batch if 0")
+)
+
+//Prints the hex encoded inclusion proof of a rollup transaction in the on-chain batch that contains it
+func main() {
+	flag.Parse()
+	var logger = logrus.New()
+	logger.SetOutput(os.Stderr)
+	client, err := ethclient.Dial("http://127.0.0.1:8545")
+	if err != nil {
+		logger.Fatal(err)
+	}
+	mybridge, err := bridge.New(common.HexToAddress(cmd.ContractAddr), client, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	hash := common.HexToHash(*txHash)
+	head, _, err := mybridge.ConfirmedHead()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	stream := optimisticrp.StreamOnChainData(context.Background(), mybridge, 0, head)
+	defer stream.Close()
+	num := uint64(0)
+	for stream.Next() {
+		switch v := stream.Event().(type) {
+		case optimisticrp.BatchEvent:
+			num++
+			if *batch != 0 && num != *batch {
+				continue
+			}
+			b, err := v.Batch.ToGolangFormat()
+			if err != nil {
+				logger.Fatal(err)
+			}
+			index, err := b.TxIndex(hash)
+			if err != nil {
+				continue
+			}
+			proof, err := b.NewTxProof(index)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if _, err := optimisticrp.VerifyTxProof(b.TxRoot, proof); err != nil {
+				logger.Fatal(err)
+			}
+			enc, err := proof.MarshalBinary()
+			if err != nil {
+				logger.Fatal(err)
+			}
+			logger.WithFields(logrus.Fields{"Batch": num, "Index": index, "TxRoot": b.TxRoot, "Bytes": len(enc), "L1Tx": v.TxHash}).Info("Transaction included")
+			fmt.Println(hex.EncodeToString(enc))
+			return
+		}
+	}
+	if err := stream.Err(); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Fatal(&optimisticrp.TransactionNotFound{Hash: hash})
+}
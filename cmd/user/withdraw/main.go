@@ -2,22 +2,23 @@ package main
 
 import (
 	"crypto/ecdsa"
+	"flag"
 	"math/big"
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/ethdb/memorydb"
-	"github.com/ethereum/go-ethereum/trie"
-	"github.com/rogercoll/optimisticrp"
 	"github.com/rogercoll/optimisticrp/aggregator"
 	"github.com/rogercoll/optimisticrp/bridge"
 	"github.com/rogercoll/optimisticrp/cmd"
 	"github.com/sirupsen/logrus"
 )
 
+var datadir = flag.String("datadir", "", "Directory where the accounts trie is persisted, kept in memory if empty")
+
 func main() {
+	flag.Parse()
 	var logger = logrus.New()
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.DebugLevel)
@@ -30,14 +31,11 @@ func main() {
 	if err != nil {
 		logger.Fatal(err)
 	}
-	var (
-		diskdb = memorydb.New()
-		triedb = trie.NewDatabase(diskdb)
-	)
-	tr, err := optimisticrp.NewTrie(triedb)
+	db, tr, err := cmd.OpenAccountsTrie(*datadir)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	defer db.Close()
 	privateKey, err := crypto.HexToECDSA(cmd.WithdrawerPriv)
 	if err != nil {
 		logger.Fatal(err)
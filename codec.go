@@ -0,0 +1,263 @@
+package optimisticrp
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//Batch calldata versions, the contract only reads the first two items of the batch list (previous and new state roots)
+//so every version keeps them, places the transactions in the third item and the transactions root in the fourth:
+//LegacyBatchVersion is the SolidityBatch RLP list, the only format prove_fraud understands. It has no version byte on the wire
+//CompactBatchVersion is a byte string: version byte + RLP of the address table and the transactions with variable length amounts
+//DeflateBatchVersion is CompactBatchVersion with the RLP compressed by DEFLATE
+const (
+	LegacyBatchVersion  byte = 0
+	CompactBatchVersion byte = 1
+	DeflateBatchVersion byte = 2
+)
+
+//Decompressed batches larger than this are rejected
+const maxBatchSize = 16 * 1024 * 1024
+
+type compactTransaction struct {
+	Value   *big.Int
+	Gas     *big.Int
+	To      uint64 //index in the address table
+	From    uint64
+	Nonce   uint64
+	V, R, S *big.Int
+	Token   uint64
+	//only typed transactions have it, transfers keep the previous encoding
+	Typed []compactTxType `rlp:"tail"`
+}
+
+type compactTxType struct {
+	Type TxType
+	Data []byte //multi-send payments are encoded as compactPayment
+}
+
+type compactPayment struct {
+	To    uint64
+	Value *big.Int
+}
+
+type compactBatch struct {
+	Addresses         []common.Address
+	Transactions      []compactTransaction
+	IntermediateRoots []IntermediateRoot `rlp:"tail"`
+}
+
+//rawBatch splits the calldata without decoding the transactions
+type rawBatch struct {
+	PrevStateRoot     common.Hash
+	StateRoot         common.Hash
+	Transactions      rlp.RawValue
+	TxRoot            common.Hash
+	IntermediateRoots []rlp.RawValue `rlp:"tail"` //legacy batches only
+}
+
+//EncodeBatch returns the newBatch calldata of the batch in the given version, the transactions root is computed from its transactions
+func EncodeBatch(b *Batch, version byte) ([]byte, error) {
+	txRoot, err := b.ComputeTxRoot()
+	if err != nil {
+		return nil, err
+	}
+	if version == LegacyBatchVersion {
+		sb := b.SolidityFormat()
+		sb.TxRoot = txRoot
+		return rlp.EncodeToBytes(sb)
+	}
+	if version != CompactBatchVersion && version != DeflateBatchVersion {
+		return nil, fmt.Errorf("%s Unknown batch version %v", OPR_BANNER, version)
+	}
+	cb, err := newCompactBatch(b)
+	if err != nil {
+		return nil, err
+	}
+	body, err := rlp.EncodeToBytes(cb)
+	if err != nil {
+		return nil, err
+	}
+	if version == DeflateBatchVersion {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+	payload := append([]byte{version}, body...)
+	return rlp.EncodeToBytes([]interface{}{b.PrevStateRoot, b.StateRoot, payload, txRoot})
+}
+
+//DecodeBatch decodes the newBatch calldata of any version, legacy batches are detected as their transactions are a RLP list
+//The transactions root is recomputed from the decoded transactions, InvalidTxRoot is returned if it does not match the calldata one
+func DecodeBatch(data []byte) (*Batch, error) {
+	var raw rawBatch
+	if err := rlp.DecodeBytes(data, &raw); err != nil {
+		return nil, err
+	}
+	kind, payload, _, err := rlp.Split(raw.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	if kind == rlp.List {
+		var sb SolidityBatch
+		if err := rlp.DecodeBytes(data, &sb); err != nil {
+			return nil, err
+		}
+		b, err := sb.ToGolangFormat()
+		if err != nil {
+			return nil, err
+		}
+		return &b, checkTxRoot(&b, raw.TxRoot)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("%s Batch without version byte", OPR_BANNER)
+	}
+	version, body := payload[0], payload[1:]
+	switch version {
+	case CompactBatchVersion:
+	case DeflateBatchVersion:
+		r := flate.NewReader(bytes.NewReader(body))
+		defer r.Close()
+		body, err = ioutil.ReadAll(io.LimitReader(r, maxBatchSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxBatchSize {
+			return nil, fmt.Errorf("%s Decompressed batch exceeds %v bytes", OPR_BANNER, maxBatchSize)
+		}
+	default:
+		return nil, fmt.Errorf("%s Unknown batch version %v", OPR_BANNER, version)
+	}
+	var cb compactBatch
+	if err := rlp.DecodeBytes(body, &cb); err != nil {
+		return nil, err
+	}
+	address := func(i uint64) (common.Address, error) {
+		if i >= uint64(len(cb.Addresses)) {
+			return common.Address{}, fmt.Errorf("%s Address index %v out of the batch table", OPR_BANNER, i)
+		}
+		return cb.Addresses[i], nil
+	}
+	b := &Batch{PrevStateRoot: raw.PrevStateRoot, StateRoot: raw.StateRoot, IntermediateRoots: cb.IntermediateRoots}
+	for _, ctx := range cb.Transactions {
+		tx := Transaction{Value: ctx.Value, Gas: ctx.Gas, Nonce: ctx.Nonce, V: ctx.V, R: ctx.R, S: ctx.S}
+		switch len(ctx.Typed) {
+		case 0:
+		case 1:
+			if ctx.Typed[0].Type == TransferTxType || ctx.Typed[0].Type > MaxTxType {
+				return nil, fmt.Errorf("%s Invalid typed transaction envelope %d", OPR_BANNER, ctx.Typed[0].Type)
+			}
+			tx.Type, tx.Data = ctx.Typed[0].Type, ctx.Typed[0].Data
+			if tx.Type == MultiSendTxType {
+				if tx.Data, err = expandPayments(tx.Data, address); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s Transaction with several types", OPR_BANNER)
+		}
+		if tx.To, err = address(ctx.To); err != nil {
+			return nil, err
+		}
+		if tx.From, err = address(ctx.From); err != nil {
+			return nil, err
+		}
+		if tx.Token, err = address(ctx.Token); err != nil {
+			return nil, err
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	return b, checkTxRoot(b, raw.TxRoot)
+}
+
+//checkTxRoot sets the transactions root of a decoded batch if it matches the claimed one
+func checkTxRoot(b *Batch, claimed common.Hash) error {
+	txRoot, err := b.ComputeTxRoot()
+	if err != nil {
+		return err
+	}
+	if txRoot != claimed {
+		return &InvalidTxRoot{Claimed: claimed, Computed: txRoot}
+	}
+	b.TxRoot = txRoot
+	return nil
+}
+
+//newCompactBatch replaces the transactions addresses by their position in the address table, in order of appearance
+func newCompactBatch(b *Batch) (compactBatch, error) {
+	cb := compactBatch{IntermediateRoots: b.IntermediateRoots}
+	indexes := make(map[common.Address]uint64)
+	index := func(addr common.Address) uint64 {
+		i, ok := indexes[addr]
+		if !ok {
+			i = uint64(len(cb.Addresses))
+			indexes[addr] = i
+			cb.Addresses = append(cb.Addresses, addr)
+		}
+		return i
+	}
+	for _, tx := range b.Transactions {
+		ctx := compactTransaction{
+			Value: tx.Value,
+			Gas:   tx.Fee(),
+			To:    index(tx.To),
+			From:  index(tx.From),
+			Nonce: tx.Nonce,
+			V:     tx.V,
+			R:     tx.R,
+			S:     tx.S,
+			Token: index(tx.Token),
+		}
+		if tx.Type != TransferTxType {
+			ctx.Typed = []compactTxType{{tx.Type, tx.Data}}
+		}
+		if tx.Type == MultiSendTxType {
+			payments, err := decodePayments(tx.Data)
+			if err != nil {
+				return cb, err
+			}
+			compact := make([]compactPayment, len(payments))
+			for i, payment := range payments {
+				compact[i] = compactPayment{index(payment.To), payment.Value}
+			}
+			if ctx.Typed[0].Data, err = rlp.EncodeToBytes(compact); err != nil {
+				return cb, err
+			}
+		}
+		cb.Transactions = append(cb.Transactions, ctx)
+	}
+	return cb, nil
+}
+
+//expandPayments rebuilds the multi-send Data from its compact payments
+func expandPayments(data []byte, address func(uint64) (common.Address, error)) ([]byte, error) {
+	var compact []compactPayment
+	if err := rlp.DecodeBytes(data, &compact); err != nil {
+		return nil, err
+	}
+	payments := make([]Payment, len(compact))
+	for i, payment := range compact {
+		to, err := address(payment.To)
+		if err != nil {
+			return nil, err
+		}
+		payments[i] = Payment{to, payment.Value}
+	}
+	return rlp.EncodeToBytes(payments)
+}
@@ -27,7 +27,7 @@ var (
 )
 
 // ContractsABI is the input ABI used to generate the binding from.
-const ContractsABI = "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_lock_time\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_required_bond\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Fraud_Proved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Invalid_Proof\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_withdraw\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"aggregators\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"bond\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"deposit\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_submitter\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"lock_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_batch\",\"type\":\"bytes\"}],\"name\":\"newBatch\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"prev_stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"_lastBatch\",\"type\":\"bytes\"}],\"name\":\"prove_fraud\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"remaining_proof_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"required_bond\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"valid_stateRoots\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]"
+const ContractsABI = "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_lock_time\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_required_bond\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Fraud_Proved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Invalid_Proof\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"submitter\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"prevStateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"}],\"name\":\"New_Batch\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"receipt\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Withdrawal_Claim\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_withdraw\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"aggregators\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"bond\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_nonce\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"claimWithdrawal\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"deposit\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_submitter\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"lock_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_batch\",\"type\":\"bytes\"}],\"name\":\"newBatch\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"prev_stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"_lastBatch\",\"type\":\"bytes\"}],\"name\":\"prove_fraud\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"remaining_proof_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"required_bond\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"valid_stateRoots\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_user\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_nonce\",\"type\":\"uint256\"}],\"name\":\"withdrawal_receipt\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"pure\",\"type\":\"function\"}]"
 
 // Contracts is an auto generated Go binding around an Ethereum contract.
 type Contracts struct {
@@ -450,6 +450,37 @@ func (_Contracts *ContractsCallerSession) ValidStateRoots(arg0 [32]byte) (bool,
 	return _Contracts.Contract.ValidStateRoots(&_Contracts.CallOpts, arg0)
 }
 
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_Contracts *ContractsCaller) WithdrawalReceipt(opts *bind.CallOpts, _user common.Address, _nonce *big.Int) (common.Address, error) {
+	var out []interface{}
+	err := _Contracts.contract.Call(opts, &out, "withdrawal_receipt", _user, _nonce)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_Contracts *ContractsSession) WithdrawalReceipt(_user common.Address, _nonce *big.Int) (common.Address, error) {
+	return _Contracts.Contract.WithdrawalReceipt(&_Contracts.CallOpts, _user, _nonce)
+}
+
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_Contracts *ContractsCallerSession) WithdrawalReceipt(_user common.Address, _nonce *big.Int) (common.Address, error) {
+	return _Contracts.Contract.WithdrawalReceipt(&_Contracts.CallOpts, _user, _nonce)
+}
+
 // Bond is a paid mutator transaction binding the contract method 0x64c9ec6f.
 //
 // Solidity: function bond() payable returns()
@@ -471,6 +502,27 @@ func (_Contracts *ContractsTransactorSession) Bond() (*types.Transaction, error)
 	return _Contracts.Contract.Bond(&_Contracts.TransactOpts)
 }
 
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_Contracts *ContractsTransactor) ClaimWithdrawal(opts *bind.TransactOpts, _nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _Contracts.contract.Transact(opts, "claimWithdrawal", _nonce, _value, _proof, _root)
+}
+
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_Contracts *ContractsSession) ClaimWithdrawal(_nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _Contracts.Contract.ClaimWithdrawal(&_Contracts.TransactOpts, _nonce, _value, _proof, _root)
+}
+
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_Contracts *ContractsTransactorSession) ClaimWithdrawal(_nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _Contracts.Contract.ClaimWithdrawal(&_Contracts.TransactOpts, _nonce, _value, _proof, _root)
+}
+
 // Deposit is a paid mutator transaction binding the contract method 0xd0e30db0.
 //
 // Solidity: function deposit() payable returns()
@@ -823,6 +875,152 @@ func (_Contracts *ContractsFilterer) ParseInvalidProof(log types.Log) (*Contract
 	return event, nil
 }
 
+// ContractsNewBatchIterator is returned from FilterNewBatch and is used to iterate over the raw logs and unpacked data for NewBatch events raised by the Contracts contract.
+type ContractsNewBatchIterator struct {
+	Event *ContractsNewBatch // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsNewBatchIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsNewBatch)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsNewBatch)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsNewBatchIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsNewBatchIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsNewBatch represents a NewBatch event raised by the Contracts contract.
+type ContractsNewBatch struct {
+	Submitter     common.Address
+	PrevStateRoot [32]byte
+	StateRoot     [32]byte
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewBatch is a free log retrieval operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_Contracts *ContractsFilterer) FilterNewBatch(opts *bind.FilterOpts, submitter []common.Address) (*ContractsNewBatchIterator, error) {
+
+	var submitterRule []interface{}
+	for _, submitterItem := range submitter {
+		submitterRule = append(submitterRule, submitterItem)
+	}
+
+	logs, sub, err := _Contracts.contract.FilterLogs(opts, "New_Batch", submitterRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsNewBatchIterator{contract: _Contracts.contract, event: "New_Batch", logs: logs, sub: sub}, nil
+}
+
+// WatchNewBatch is a free log subscription operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_Contracts *ContractsFilterer) WatchNewBatch(opts *bind.WatchOpts, sink chan<- *ContractsNewBatch, submitter []common.Address) (event.Subscription, error) {
+
+	var submitterRule []interface{}
+	for _, submitterItem := range submitter {
+		submitterRule = append(submitterRule, submitterItem)
+	}
+
+	logs, sub, err := _Contracts.contract.WatchLogs(opts, "New_Batch", submitterRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsNewBatch)
+				if err := _Contracts.contract.UnpackLog(event, "New_Batch", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewBatch is a log parse operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_Contracts *ContractsFilterer) ParseNewBatch(log types.Log) (*ContractsNewBatch, error) {
+	event := new(ContractsNewBatch)
+	if err := _Contracts.contract.UnpackLog(event, "New_Batch", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // ContractsNewDepositIterator is returned from FilterNewDeposit and is used to iterate over the raw logs and unpacked data for NewDeposit events raised by the Contracts contract.
 type ContractsNewDepositIterator struct {
 	Event *ContractsNewDeposit // Event containing the contract specifics and raw log
@@ -959,6 +1157,143 @@ func (_Contracts *ContractsFilterer) ParseNewDeposit(log types.Log) (*ContractsN
 	return event, nil
 }
 
+// ContractsNewWithdrawalClaimIterator is returned from FilterNewWithdrawalClaim and is used to iterate over the raw logs and unpacked data for NewWithdrawalClaim events raised by the Contracts contract.
+type ContractsNewWithdrawalClaimIterator struct {
+	Event *ContractsNewWithdrawalClaim // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsNewWithdrawalClaimIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsNewWithdrawalClaim)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsNewWithdrawalClaim)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsNewWithdrawalClaimIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsNewWithdrawalClaimIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsNewWithdrawalClaim represents a NewWithdrawalClaim event raised by the Contracts contract.
+type ContractsNewWithdrawalClaim struct {
+	User      common.Address
+	Receipt   common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewWithdrawalClaim is a free log retrieval operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_Contracts *ContractsFilterer) FilterNewWithdrawalClaim(opts *bind.FilterOpts) (*ContractsNewWithdrawalClaimIterator, error) {
+
+	logs, sub, err := _Contracts.contract.FilterLogs(opts, "New_Withdrawal_Claim")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsNewWithdrawalClaimIterator{contract: _Contracts.contract, event: "New_Withdrawal_Claim", logs: logs, sub: sub}, nil
+}
+
+// WatchNewWithdrawalClaim is a free log subscription operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_Contracts *ContractsFilterer) WatchNewWithdrawalClaim(opts *bind.WatchOpts, sink chan<- *ContractsNewWithdrawalClaim) (event.Subscription, error) {
+
+	logs, sub, err := _Contracts.contract.WatchLogs(opts, "New_Withdrawal_Claim")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsNewWithdrawalClaim)
+				if err := _Contracts.contract.UnpackLog(event, "New_Withdrawal_Claim", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewWithdrawalClaim is a log parse operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_Contracts *ContractsFilterer) ParseNewWithdrawalClaim(log types.Log) (*ContractsNewWithdrawalClaim, error) {
+	event := new(ContractsNewWithdrawalClaim)
+	if err := _Contracts.contract.UnpackLog(event, "New_Withdrawal_Claim", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // ContractsNewWithdrawIterator is returned from FilterNewWithdraw and is used to iterate over the raw logs and unpacked data for NewWithdraw events raised by the Contracts contract.
 type ContractsNewWithdrawIterator struct {
 	Event *ContractsNewWithdraw // Event containing the contract specifics and raw log
@@ -0,0 +1,1804 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contracts
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ContractsERC20ABI is the input ABI used to generate the binding from.
+const ContractsERC20ABI = "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_lock_time\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_required_bond\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Fraud_Proved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"challenger\",\"type\":\"address\"}],\"name\":\"Invalid_Proof\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"submitter\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"prevStateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"}],\"name\":\"New_Batch\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Token_Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Token_Withdraw\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"receipt\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_Withdrawal_Claim\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"stateRoot\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"New_withdraw\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"aggregators\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"bond\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_nonce\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"claimTokenWithdrawal\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_nonce\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"claimWithdrawal\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"deposit\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_amount\",\"type\":\"uint256\"}],\"name\":\"depositToken\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_submitter\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"last_batch_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"lock_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_batch\",\"type\":\"bytes\"}],\"name\":\"newBatch\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"prev_stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"_lastBatch\",\"type\":\"bytes\"}],\"name\":\"prove_fraud\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"remaining_proof_time\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"required_bond\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"stateRoot\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"valid_stateRoots\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_token\",\"type\":\"address\"},{\"internalType\":\"bytes\",\"name\":\"_key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_value\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"_proof\",\"type\":\"bytes\"},{\"internalType\":\"bytes32\",\"name\":\"_root\",\"type\":\"bytes32\"}],\"name\":\"withdrawToken\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_user\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_nonce\",\"type\":\"uint256\"}],\"name\":\"withdrawal_receipt\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"pure\",\"type\":\"function\"}]"
+
+// ContractsERC20 is an auto generated Go binding around an Ethereum contract.
+type ContractsERC20 struct {
+	ContractsERC20Caller     // Read-only binding to the contract
+	ContractsERC20Transactor // Write-only binding to the contract
+	ContractsERC20Filterer   // Log filterer for contract events
+}
+
+// ContractsERC20Caller is an auto generated read-only Go binding around an Ethereum contract.
+type ContractsERC20Caller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ContractsERC20Transactor is an auto generated write-only Go binding around an Ethereum contract.
+type ContractsERC20Transactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ContractsERC20Filterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ContractsERC20Filterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ContractsERC20Session is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ContractsERC20Session struct {
+	Contract     *ContractsERC20   // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ContractsERC20CallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ContractsERC20CallerSession struct {
+	Contract *ContractsERC20Caller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts         // Call options to use throughout this session
+}
+
+// ContractsERC20TransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ContractsERC20TransactorSession struct {
+	Contract     *ContractsERC20Transactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts         // Transaction auth options to use throughout this session
+}
+
+// ContractsERC20Raw is an auto generated low-level Go binding around an Ethereum contract.
+type ContractsERC20Raw struct {
+	Contract *ContractsERC20 // Generic contract binding to access the raw methods on
+}
+
+// ContractsERC20CallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ContractsERC20CallerRaw struct {
+	Contract *ContractsERC20Caller // Generic read-only contract binding to access the raw methods on
+}
+
+// ContractsERC20TransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ContractsERC20TransactorRaw struct {
+	Contract *ContractsERC20Transactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewContractsERC20 creates a new instance of ContractsERC20, bound to a specific deployed contract.
+func NewContractsERC20(address common.Address, backend bind.ContractBackend) (*ContractsERC20, error) {
+	contract, err := bindContractsERC20(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20{ContractsERC20Caller: ContractsERC20Caller{contract: contract}, ContractsERC20Transactor: ContractsERC20Transactor{contract: contract}, ContractsERC20Filterer: ContractsERC20Filterer{contract: contract}}, nil
+}
+
+// NewContractsERC20Caller creates a new read-only instance of ContractsERC20, bound to a specific deployed contract.
+func NewContractsERC20Caller(address common.Address, caller bind.ContractCaller) (*ContractsERC20Caller, error) {
+	contract, err := bindContractsERC20(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20Caller{contract: contract}, nil
+}
+
+// NewContractsERC20Transactor creates a new write-only instance of ContractsERC20, bound to a specific deployed contract.
+func NewContractsERC20Transactor(address common.Address, transactor bind.ContractTransactor) (*ContractsERC20Transactor, error) {
+	contract, err := bindContractsERC20(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20Transactor{contract: contract}, nil
+}
+
+// NewContractsERC20Filterer creates a new log filterer instance of ContractsERC20, bound to a specific deployed contract.
+func NewContractsERC20Filterer(address common.Address, filterer bind.ContractFilterer) (*ContractsERC20Filterer, error) {
+	contract, err := bindContractsERC20(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20Filterer{contract: contract}, nil
+}
+
+// bindContractsERC20 binds a generic wrapper to an already deployed contract.
+func bindContractsERC20(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(ContractsERC20ABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ContractsERC20 *ContractsERC20Raw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ContractsERC20.Contract.ContractsERC20Caller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ContractsERC20 *ContractsERC20Raw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ContractsERC20Transactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ContractsERC20 *ContractsERC20Raw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ContractsERC20Transactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ContractsERC20 *ContractsERC20CallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ContractsERC20.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ContractsERC20 *ContractsERC20TransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ContractsERC20 *ContractsERC20TransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.contract.Transact(opts, method, params...)
+}
+
+// Aggregators is a free data retrieval call binding the contract method 0x112cdab9.
+//
+// Solidity: function aggregators(address ) view returns(address)
+func (_ContractsERC20 *ContractsERC20Caller) Aggregators(opts *bind.CallOpts, arg0 common.Address) (common.Address, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "aggregators", arg0)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Aggregators is a free data retrieval call binding the contract method 0x112cdab9.
+//
+// Solidity: function aggregators(address ) view returns(address)
+func (_ContractsERC20 *ContractsERC20Session) Aggregators(arg0 common.Address) (common.Address, error) {
+	return _ContractsERC20.Contract.Aggregators(&_ContractsERC20.CallOpts, arg0)
+}
+
+// Aggregators is a free data retrieval call binding the contract method 0x112cdab9.
+//
+// Solidity: function aggregators(address ) view returns(address)
+func (_ContractsERC20 *ContractsERC20CallerSession) Aggregators(arg0 common.Address) (common.Address, error) {
+	return _ContractsERC20.Contract.Aggregators(&_ContractsERC20.CallOpts, arg0)
+}
+
+// LastBatchSubmitter is a free data retrieval call binding the contract method 0xc357b60b.
+//
+// Solidity: function last_batch_submitter() view returns(address)
+func (_ContractsERC20 *ContractsERC20Caller) LastBatchSubmitter(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "last_batch_submitter")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// LastBatchSubmitter is a free data retrieval call binding the contract method 0xc357b60b.
+//
+// Solidity: function last_batch_submitter() view returns(address)
+func (_ContractsERC20 *ContractsERC20Session) LastBatchSubmitter() (common.Address, error) {
+	return _ContractsERC20.Contract.LastBatchSubmitter(&_ContractsERC20.CallOpts)
+}
+
+// LastBatchSubmitter is a free data retrieval call binding the contract method 0xc357b60b.
+//
+// Solidity: function last_batch_submitter() view returns(address)
+func (_ContractsERC20 *ContractsERC20CallerSession) LastBatchSubmitter() (common.Address, error) {
+	return _ContractsERC20.Contract.LastBatchSubmitter(&_ContractsERC20.CallOpts)
+}
+
+// LastBatchTime is a free data retrieval call binding the contract method 0xb194d0ea.
+//
+// Solidity: function last_batch_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Caller) LastBatchTime(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "last_batch_time")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// LastBatchTime is a free data retrieval call binding the contract method 0xb194d0ea.
+//
+// Solidity: function last_batch_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Session) LastBatchTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.LastBatchTime(&_ContractsERC20.CallOpts)
+}
+
+// LastBatchTime is a free data retrieval call binding the contract method 0xb194d0ea.
+//
+// Solidity: function last_batch_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20CallerSession) LastBatchTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.LastBatchTime(&_ContractsERC20.CallOpts)
+}
+
+// LockTime is a free data retrieval call binding the contract method 0x480bb7c4.
+//
+// Solidity: function lock_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Caller) LockTime(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "lock_time")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// LockTime is a free data retrieval call binding the contract method 0x480bb7c4.
+//
+// Solidity: function lock_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Session) LockTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.LockTime(&_ContractsERC20.CallOpts)
+}
+
+// LockTime is a free data retrieval call binding the contract method 0x480bb7c4.
+//
+// Solidity: function lock_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20CallerSession) LockTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.LockTime(&_ContractsERC20.CallOpts)
+}
+
+// PrevStateRoot is a free data retrieval call binding the contract method 0xf53b28aa.
+//
+// Solidity: function prev_stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20Caller) PrevStateRoot(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "prev_stateRoot")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// PrevStateRoot is a free data retrieval call binding the contract method 0xf53b28aa.
+//
+// Solidity: function prev_stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20Session) PrevStateRoot() ([32]byte, error) {
+	return _ContractsERC20.Contract.PrevStateRoot(&_ContractsERC20.CallOpts)
+}
+
+// PrevStateRoot is a free data retrieval call binding the contract method 0xf53b28aa.
+//
+// Solidity: function prev_stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20CallerSession) PrevStateRoot() ([32]byte, error) {
+	return _ContractsERC20.Contract.PrevStateRoot(&_ContractsERC20.CallOpts)
+}
+
+// RemainingProofTime is a free data retrieval call binding the contract method 0x57e76d72.
+//
+// Solidity: function remaining_proof_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Caller) RemainingProofTime(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "remaining_proof_time")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// RemainingProofTime is a free data retrieval call binding the contract method 0x57e76d72.
+//
+// Solidity: function remaining_proof_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Session) RemainingProofTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.RemainingProofTime(&_ContractsERC20.CallOpts)
+}
+
+// RemainingProofTime is a free data retrieval call binding the contract method 0x57e76d72.
+//
+// Solidity: function remaining_proof_time() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20CallerSession) RemainingProofTime() (*big.Int, error) {
+	return _ContractsERC20.Contract.RemainingProofTime(&_ContractsERC20.CallOpts)
+}
+
+// RequiredBond is a free data retrieval call binding the contract method 0xb2055400.
+//
+// Solidity: function required_bond() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Caller) RequiredBond(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "required_bond")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// RequiredBond is a free data retrieval call binding the contract method 0xb2055400.
+//
+// Solidity: function required_bond() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20Session) RequiredBond() (*big.Int, error) {
+	return _ContractsERC20.Contract.RequiredBond(&_ContractsERC20.CallOpts)
+}
+
+// RequiredBond is a free data retrieval call binding the contract method 0xb2055400.
+//
+// Solidity: function required_bond() view returns(uint256)
+func (_ContractsERC20 *ContractsERC20CallerSession) RequiredBond() (*big.Int, error) {
+	return _ContractsERC20.Contract.RequiredBond(&_ContractsERC20.CallOpts)
+}
+
+// StateRoot is a free data retrieval call binding the contract method 0x9588eca2.
+//
+// Solidity: function stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20Caller) StateRoot(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "stateRoot")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// StateRoot is a free data retrieval call binding the contract method 0x9588eca2.
+//
+// Solidity: function stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20Session) StateRoot() ([32]byte, error) {
+	return _ContractsERC20.Contract.StateRoot(&_ContractsERC20.CallOpts)
+}
+
+// StateRoot is a free data retrieval call binding the contract method 0x9588eca2.
+//
+// Solidity: function stateRoot() view returns(bytes32)
+func (_ContractsERC20 *ContractsERC20CallerSession) StateRoot() ([32]byte, error) {
+	return _ContractsERC20.Contract.StateRoot(&_ContractsERC20.CallOpts)
+}
+
+// ValidStateRoots is a free data retrieval call binding the contract method 0xe4481e9c.
+//
+// Solidity: function valid_stateRoots(bytes32 ) view returns(bool)
+func (_ContractsERC20 *ContractsERC20Caller) ValidStateRoots(opts *bind.CallOpts, arg0 [32]byte) (bool, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "valid_stateRoots", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// ValidStateRoots is a free data retrieval call binding the contract method 0xe4481e9c.
+//
+// Solidity: function valid_stateRoots(bytes32 ) view returns(bool)
+func (_ContractsERC20 *ContractsERC20Session) ValidStateRoots(arg0 [32]byte) (bool, error) {
+	return _ContractsERC20.Contract.ValidStateRoots(&_ContractsERC20.CallOpts, arg0)
+}
+
+// ValidStateRoots is a free data retrieval call binding the contract method 0xe4481e9c.
+//
+// Solidity: function valid_stateRoots(bytes32 ) view returns(bool)
+func (_ContractsERC20 *ContractsERC20CallerSession) ValidStateRoots(arg0 [32]byte) (bool, error) {
+	return _ContractsERC20.Contract.ValidStateRoots(&_ContractsERC20.CallOpts, arg0)
+}
+
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_ContractsERC20 *ContractsERC20Caller) WithdrawalReceipt(opts *bind.CallOpts, _user common.Address, _nonce *big.Int) (common.Address, error) {
+	var out []interface{}
+	err := _ContractsERC20.contract.Call(opts, &out, "withdrawal_receipt", _user, _nonce)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_ContractsERC20 *ContractsERC20Session) WithdrawalReceipt(_user common.Address, _nonce *big.Int) (common.Address, error) {
+	return _ContractsERC20.Contract.WithdrawalReceipt(&_ContractsERC20.CallOpts, _user, _nonce)
+}
+
+// WithdrawalReceipt is a free data retrieval call binding the contract method 0x989178c6.
+//
+// Solidity: function withdrawal_receipt(address _user, uint256 _nonce) pure returns(address)
+func (_ContractsERC20 *ContractsERC20CallerSession) WithdrawalReceipt(_user common.Address, _nonce *big.Int) (common.Address, error) {
+	return _ContractsERC20.Contract.WithdrawalReceipt(&_ContractsERC20.CallOpts, _user, _nonce)
+}
+
+// Bond is a paid mutator transaction binding the contract method 0x64c9ec6f.
+//
+// Solidity: function bond() payable returns()
+func (_ContractsERC20 *ContractsERC20Transactor) Bond(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "bond")
+}
+
+// Bond is a paid mutator transaction binding the contract method 0x64c9ec6f.
+//
+// Solidity: function bond() payable returns()
+func (_ContractsERC20 *ContractsERC20Session) Bond() (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Bond(&_ContractsERC20.TransactOpts)
+}
+
+// Bond is a paid mutator transaction binding the contract method 0x64c9ec6f.
+//
+// Solidity: function bond() payable returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) Bond() (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Bond(&_ContractsERC20.TransactOpts)
+}
+
+// ClaimTokenWithdrawal is a paid mutator transaction binding the contract method 0x770984df.
+//
+// Solidity: function claimTokenWithdrawal(address _token, uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) ClaimTokenWithdrawal(opts *bind.TransactOpts, _token common.Address, _nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "claimTokenWithdrawal", _token, _nonce, _value, _proof, _root)
+}
+
+// ClaimTokenWithdrawal is a paid mutator transaction binding the contract method 0x770984df.
+//
+// Solidity: function claimTokenWithdrawal(address _token, uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Session) ClaimTokenWithdrawal(_token common.Address, _nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ClaimTokenWithdrawal(&_ContractsERC20.TransactOpts, _token, _nonce, _value, _proof, _root)
+}
+
+// ClaimTokenWithdrawal is a paid mutator transaction binding the contract method 0x770984df.
+//
+// Solidity: function claimTokenWithdrawal(address _token, uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) ClaimTokenWithdrawal(_token common.Address, _nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ClaimTokenWithdrawal(&_ContractsERC20.TransactOpts, _token, _nonce, _value, _proof, _root)
+}
+
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) ClaimWithdrawal(opts *bind.TransactOpts, _nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "claimWithdrawal", _nonce, _value, _proof, _root)
+}
+
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Session) ClaimWithdrawal(_nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ClaimWithdrawal(&_ContractsERC20.TransactOpts, _nonce, _value, _proof, _root)
+}
+
+// ClaimWithdrawal is a paid mutator transaction binding the contract method 0xfbd312df.
+//
+// Solidity: function claimWithdrawal(uint256 _nonce, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) ClaimWithdrawal(_nonce *big.Int, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ClaimWithdrawal(&_ContractsERC20.TransactOpts, _nonce, _value, _proof, _root)
+}
+
+// Deposit is a paid mutator transaction binding the contract method 0xd0e30db0.
+//
+// Solidity: function deposit() payable returns()
+func (_ContractsERC20 *ContractsERC20Transactor) Deposit(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "deposit")
+}
+
+// Deposit is a paid mutator transaction binding the contract method 0xd0e30db0.
+//
+// Solidity: function deposit() payable returns()
+func (_ContractsERC20 *ContractsERC20Session) Deposit() (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Deposit(&_ContractsERC20.TransactOpts)
+}
+
+// Deposit is a paid mutator transaction binding the contract method 0xd0e30db0.
+//
+// Solidity: function deposit() payable returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) Deposit() (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Deposit(&_ContractsERC20.TransactOpts)
+}
+
+// DepositToken is a paid mutator transaction binding the contract method 0x338b5dea.
+//
+// Solidity: function depositToken(address _token, uint256 _amount) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) DepositToken(opts *bind.TransactOpts, _token common.Address, _amount *big.Int) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "depositToken", _token, _amount)
+}
+
+// DepositToken is a paid mutator transaction binding the contract method 0x338b5dea.
+//
+// Solidity: function depositToken(address _token, uint256 _amount) returns()
+func (_ContractsERC20 *ContractsERC20Session) DepositToken(_token common.Address, _amount *big.Int) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.DepositToken(&_ContractsERC20.TransactOpts, _token, _amount)
+}
+
+// DepositToken is a paid mutator transaction binding the contract method 0x338b5dea.
+//
+// Solidity: function depositToken(address _token, uint256 _amount) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) DepositToken(_token common.Address, _amount *big.Int) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.DepositToken(&_ContractsERC20.TransactOpts, _token, _amount)
+}
+
+// NewBatch is a paid mutator transaction binding the contract method 0xdbcf9bd2.
+//
+// Solidity: function newBatch(bytes _batch) returns(string)
+func (_ContractsERC20 *ContractsERC20Transactor) NewBatch(opts *bind.TransactOpts, _batch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "newBatch", _batch)
+}
+
+// NewBatch is a paid mutator transaction binding the contract method 0xdbcf9bd2.
+//
+// Solidity: function newBatch(bytes _batch) returns(string)
+func (_ContractsERC20 *ContractsERC20Session) NewBatch(_batch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.NewBatch(&_ContractsERC20.TransactOpts, _batch)
+}
+
+// NewBatch is a paid mutator transaction binding the contract method 0xdbcf9bd2.
+//
+// Solidity: function newBatch(bytes _batch) returns(string)
+func (_ContractsERC20 *ContractsERC20TransactorSession) NewBatch(_batch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.NewBatch(&_ContractsERC20.TransactOpts, _batch)
+}
+
+// ProveFraud is a paid mutator transaction binding the contract method 0x829e6914.
+//
+// Solidity: function prove_fraud(bytes _key, bytes _value, bytes _proof, bytes32 _root, bytes _lastBatch) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) ProveFraud(opts *bind.TransactOpts, _key []byte, _value []byte, _proof []byte, _root [32]byte, _lastBatch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "prove_fraud", _key, _value, _proof, _root, _lastBatch)
+}
+
+// ProveFraud is a paid mutator transaction binding the contract method 0x829e6914.
+//
+// Solidity: function prove_fraud(bytes _key, bytes _value, bytes _proof, bytes32 _root, bytes _lastBatch) returns()
+func (_ContractsERC20 *ContractsERC20Session) ProveFraud(_key []byte, _value []byte, _proof []byte, _root [32]byte, _lastBatch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ProveFraud(&_ContractsERC20.TransactOpts, _key, _value, _proof, _root, _lastBatch)
+}
+
+// ProveFraud is a paid mutator transaction binding the contract method 0x829e6914.
+//
+// Solidity: function prove_fraud(bytes _key, bytes _value, bytes _proof, bytes32 _root, bytes _lastBatch) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) ProveFraud(_key []byte, _value []byte, _proof []byte, _root [32]byte, _lastBatch []byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.ProveFraud(&_ContractsERC20.TransactOpts, _key, _value, _proof, _root, _lastBatch)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x7a3e8408.
+//
+// Solidity: function withdraw(bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) Withdraw(opts *bind.TransactOpts, _key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "withdraw", _key, _value, _proof, _root)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x7a3e8408.
+//
+// Solidity: function withdraw(bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Session) Withdraw(_key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Withdraw(&_ContractsERC20.TransactOpts, _key, _value, _proof, _root)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x7a3e8408.
+//
+// Solidity: function withdraw(bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) Withdraw(_key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.Withdraw(&_ContractsERC20.TransactOpts, _key, _value, _proof, _root)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x9221e844.
+//
+// Solidity: function withdrawToken(address _token, bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Transactor) WithdrawToken(opts *bind.TransactOpts, _token common.Address, _key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.contract.Transact(opts, "withdrawToken", _token, _key, _value, _proof, _root)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x9221e844.
+//
+// Solidity: function withdrawToken(address _token, bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20Session) WithdrawToken(_token common.Address, _key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.WithdrawToken(&_ContractsERC20.TransactOpts, _token, _key, _value, _proof, _root)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x9221e844.
+//
+// Solidity: function withdrawToken(address _token, bytes _key, bytes _value, bytes _proof, bytes32 _root) returns()
+func (_ContractsERC20 *ContractsERC20TransactorSession) WithdrawToken(_token common.Address, _key []byte, _value []byte, _proof []byte, _root [32]byte) (*types.Transaction, error) {
+	return _ContractsERC20.Contract.WithdrawToken(&_ContractsERC20.TransactOpts, _token, _key, _value, _proof, _root)
+}
+
+// ContractsERC20FraudProvedIterator is returned from FilterFraudProved and is used to iterate over the raw logs and unpacked data for FraudProved events raised by the ContractsERC20 contract.
+type ContractsERC20FraudProvedIterator struct {
+	Event *ContractsERC20FraudProved // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20FraudProvedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20FraudProved)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20FraudProved)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20FraudProvedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20FraudProvedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20FraudProved represents a FraudProved event raised by the ContractsERC20 contract.
+type ContractsERC20FraudProved struct {
+	Challenger common.Address
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterFraudProved is a free log retrieval operation binding the contract event 0x1f35b91450baf2072ff37f7dbefd90eace09b98ad6d40c13ae7af6f64aacb849.
+//
+// Solidity: event Fraud_Proved(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterFraudProved(opts *bind.FilterOpts) (*ContractsERC20FraudProvedIterator, error) {
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "Fraud_Proved")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20FraudProvedIterator{contract: _ContractsERC20.contract, event: "Fraud_Proved", logs: logs, sub: sub}, nil
+}
+
+// WatchFraudProved is a free log subscription operation binding the contract event 0x1f35b91450baf2072ff37f7dbefd90eace09b98ad6d40c13ae7af6f64aacb849.
+//
+// Solidity: event Fraud_Proved(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchFraudProved(opts *bind.WatchOpts, sink chan<- *ContractsERC20FraudProved) (event.Subscription, error) {
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "Fraud_Proved")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20FraudProved)
+				if err := _ContractsERC20.contract.UnpackLog(event, "Fraud_Proved", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseFraudProved is a log parse operation binding the contract event 0x1f35b91450baf2072ff37f7dbefd90eace09b98ad6d40c13ae7af6f64aacb849.
+//
+// Solidity: event Fraud_Proved(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseFraudProved(log types.Log) (*ContractsERC20FraudProved, error) {
+	event := new(ContractsERC20FraudProved)
+	if err := _ContractsERC20.contract.UnpackLog(event, "Fraud_Proved", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20InvalidProofIterator is returned from FilterInvalidProof and is used to iterate over the raw logs and unpacked data for InvalidProof events raised by the ContractsERC20 contract.
+type ContractsERC20InvalidProofIterator struct {
+	Event *ContractsERC20InvalidProof // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20InvalidProofIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20InvalidProof)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20InvalidProof)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20InvalidProofIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20InvalidProofIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20InvalidProof represents a InvalidProof event raised by the ContractsERC20 contract.
+type ContractsERC20InvalidProof struct {
+	Challenger common.Address
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterInvalidProof is a free log retrieval operation binding the contract event 0x20fc9549bb0aaddcc67903f8d9af4fe5f102d559cd1fcdbaa74dd4b198b44afc.
+//
+// Solidity: event Invalid_Proof(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterInvalidProof(opts *bind.FilterOpts) (*ContractsERC20InvalidProofIterator, error) {
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "Invalid_Proof")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20InvalidProofIterator{contract: _ContractsERC20.contract, event: "Invalid_Proof", logs: logs, sub: sub}, nil
+}
+
+// WatchInvalidProof is a free log subscription operation binding the contract event 0x20fc9549bb0aaddcc67903f8d9af4fe5f102d559cd1fcdbaa74dd4b198b44afc.
+//
+// Solidity: event Invalid_Proof(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchInvalidProof(opts *bind.WatchOpts, sink chan<- *ContractsERC20InvalidProof) (event.Subscription, error) {
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "Invalid_Proof")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20InvalidProof)
+				if err := _ContractsERC20.contract.UnpackLog(event, "Invalid_Proof", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseInvalidProof is a log parse operation binding the contract event 0x20fc9549bb0aaddcc67903f8d9af4fe5f102d559cd1fcdbaa74dd4b198b44afc.
+//
+// Solidity: event Invalid_Proof(address challenger)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseInvalidProof(log types.Log) (*ContractsERC20InvalidProof, error) {
+	event := new(ContractsERC20InvalidProof)
+	if err := _ContractsERC20.contract.UnpackLog(event, "Invalid_Proof", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewBatchIterator is returned from FilterNewBatch and is used to iterate over the raw logs and unpacked data for NewBatch events raised by the ContractsERC20 contract.
+type ContractsERC20NewBatchIterator struct {
+	Event *ContractsERC20NewBatch // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewBatchIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewBatch)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewBatch)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewBatchIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewBatchIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewBatch represents a NewBatch event raised by the ContractsERC20 contract.
+type ContractsERC20NewBatch struct {
+	Submitter     common.Address
+	PrevStateRoot [32]byte
+	StateRoot     [32]byte
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewBatch is a free log retrieval operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewBatch(opts *bind.FilterOpts, submitter []common.Address) (*ContractsERC20NewBatchIterator, error) {
+
+	var submitterRule []interface{}
+	for _, submitterItem := range submitter {
+		submitterRule = append(submitterRule, submitterItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_Batch", submitterRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewBatchIterator{contract: _ContractsERC20.contract, event: "New_Batch", logs: logs, sub: sub}, nil
+}
+
+// WatchNewBatch is a free log subscription operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewBatch(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewBatch, submitter []common.Address) (event.Subscription, error) {
+
+	var submitterRule []interface{}
+	for _, submitterItem := range submitter {
+		submitterRule = append(submitterRule, submitterItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_Batch", submitterRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewBatch)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_Batch", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewBatch is a log parse operation binding the contract event 0xb89dcc5bb68008e680c1d0472be346bb47bbc837de8a861c5c9d99b92b2a5101.
+//
+// Solidity: event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewBatch(log types.Log) (*ContractsERC20NewBatch, error) {
+	event := new(ContractsERC20NewBatch)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_Batch", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewDepositIterator is returned from FilterNewDeposit and is used to iterate over the raw logs and unpacked data for NewDeposit events raised by the ContractsERC20 contract.
+type ContractsERC20NewDepositIterator struct {
+	Event *ContractsERC20NewDeposit // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewDepositIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewDeposit)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewDeposit)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewDepositIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewDepositIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewDeposit represents a NewDeposit event raised by the ContractsERC20 contract.
+type ContractsERC20NewDeposit struct {
+	User      common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewDeposit is a free log retrieval operation binding the contract event 0xcd383a129c9295e144cae64b0726b69050054843ac23c8ca12b84fd69464ed8c.
+//
+// Solidity: event New_Deposit(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewDeposit(opts *bind.FilterOpts) (*ContractsERC20NewDepositIterator, error) {
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_Deposit")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewDepositIterator{contract: _ContractsERC20.contract, event: "New_Deposit", logs: logs, sub: sub}, nil
+}
+
+// WatchNewDeposit is a free log subscription operation binding the contract event 0xcd383a129c9295e144cae64b0726b69050054843ac23c8ca12b84fd69464ed8c.
+//
+// Solidity: event New_Deposit(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewDeposit(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewDeposit) (event.Subscription, error) {
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_Deposit")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewDeposit)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_Deposit", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewDeposit is a log parse operation binding the contract event 0xcd383a129c9295e144cae64b0726b69050054843ac23c8ca12b84fd69464ed8c.
+//
+// Solidity: event New_Deposit(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewDeposit(log types.Log) (*ContractsERC20NewDeposit, error) {
+	event := new(ContractsERC20NewDeposit)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_Deposit", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewTokenDepositIterator is returned from FilterNewTokenDeposit and is used to iterate over the raw logs and unpacked data for NewTokenDeposit events raised by the ContractsERC20 contract.
+type ContractsERC20NewTokenDepositIterator struct {
+	Event *ContractsERC20NewTokenDeposit // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewTokenDepositIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewTokenDeposit)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewTokenDeposit)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewTokenDepositIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewTokenDepositIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewTokenDeposit represents a NewTokenDeposit event raised by the ContractsERC20 contract.
+type ContractsERC20NewTokenDeposit struct {
+	User      common.Address
+	Token     common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewTokenDeposit is a free log retrieval operation binding the contract event 0xcb124fe5ae8033b54bd1cd5f5c0695d6b46e4095a06263247740a5f84697e801.
+//
+// Solidity: event New_Token_Deposit(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewTokenDeposit(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*ContractsERC20NewTokenDepositIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_Token_Deposit", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewTokenDepositIterator{contract: _ContractsERC20.contract, event: "New_Token_Deposit", logs: logs, sub: sub}, nil
+}
+
+// WatchNewTokenDeposit is a free log subscription operation binding the contract event 0xcb124fe5ae8033b54bd1cd5f5c0695d6b46e4095a06263247740a5f84697e801.
+//
+// Solidity: event New_Token_Deposit(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewTokenDeposit(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewTokenDeposit, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_Token_Deposit", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewTokenDeposit)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_Token_Deposit", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewTokenDeposit is a log parse operation binding the contract event 0xcb124fe5ae8033b54bd1cd5f5c0695d6b46e4095a06263247740a5f84697e801.
+//
+// Solidity: event New_Token_Deposit(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewTokenDeposit(log types.Log) (*ContractsERC20NewTokenDeposit, error) {
+	event := new(ContractsERC20NewTokenDeposit)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_Token_Deposit", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewTokenWithdrawIterator is returned from FilterNewTokenWithdraw and is used to iterate over the raw logs and unpacked data for NewTokenWithdraw events raised by the ContractsERC20 contract.
+type ContractsERC20NewTokenWithdrawIterator struct {
+	Event *ContractsERC20NewTokenWithdraw // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewTokenWithdrawIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewTokenWithdraw)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewTokenWithdraw)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewTokenWithdrawIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewTokenWithdrawIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewTokenWithdraw represents a NewTokenWithdraw event raised by the ContractsERC20 contract.
+type ContractsERC20NewTokenWithdraw struct {
+	User      common.Address
+	Token     common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewTokenWithdraw is a free log retrieval operation binding the contract event 0x92c79360d04ee143c2d62a12785bfaba66a87d19750411717f50250ba7695f91.
+//
+// Solidity: event New_Token_Withdraw(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewTokenWithdraw(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*ContractsERC20NewTokenWithdrawIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_Token_Withdraw", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewTokenWithdrawIterator{contract: _ContractsERC20.contract, event: "New_Token_Withdraw", logs: logs, sub: sub}, nil
+}
+
+// WatchNewTokenWithdraw is a free log subscription operation binding the contract event 0x92c79360d04ee143c2d62a12785bfaba66a87d19750411717f50250ba7695f91.
+//
+// Solidity: event New_Token_Withdraw(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewTokenWithdraw(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewTokenWithdraw, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_Token_Withdraw", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewTokenWithdraw)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_Token_Withdraw", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewTokenWithdraw is a log parse operation binding the contract event 0x92c79360d04ee143c2d62a12785bfaba66a87d19750411717f50250ba7695f91.
+//
+// Solidity: event New_Token_Withdraw(address indexed user, address indexed token, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewTokenWithdraw(log types.Log) (*ContractsERC20NewTokenWithdraw, error) {
+	event := new(ContractsERC20NewTokenWithdraw)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_Token_Withdraw", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewWithdrawalClaimIterator is returned from FilterNewWithdrawalClaim and is used to iterate over the raw logs and unpacked data for NewWithdrawalClaim events raised by the ContractsERC20 contract.
+type ContractsERC20NewWithdrawalClaimIterator struct {
+	Event *ContractsERC20NewWithdrawalClaim // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewWithdrawalClaimIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewWithdrawalClaim)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewWithdrawalClaim)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewWithdrawalClaimIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewWithdrawalClaimIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewWithdrawalClaim represents a NewWithdrawalClaim event raised by the ContractsERC20 contract.
+type ContractsERC20NewWithdrawalClaim struct {
+	User      common.Address
+	Receipt   common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewWithdrawalClaim is a free log retrieval operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewWithdrawalClaim(opts *bind.FilterOpts) (*ContractsERC20NewWithdrawalClaimIterator, error) {
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_Withdrawal_Claim")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewWithdrawalClaimIterator{contract: _ContractsERC20.contract, event: "New_Withdrawal_Claim", logs: logs, sub: sub}, nil
+}
+
+// WatchNewWithdrawalClaim is a free log subscription operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewWithdrawalClaim(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewWithdrawalClaim) (event.Subscription, error) {
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_Withdrawal_Claim")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewWithdrawalClaim)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_Withdrawal_Claim", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewWithdrawalClaim is a log parse operation binding the contract event 0x30444e3c3519a29ab060b5222097766a3412325591a4902cf2700cf223930a03.
+//
+// Solidity: event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewWithdrawalClaim(log types.Log) (*ContractsERC20NewWithdrawalClaim, error) {
+	event := new(ContractsERC20NewWithdrawalClaim)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_Withdrawal_Claim", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ContractsERC20NewWithdrawIterator is returned from FilterNewWithdraw and is used to iterate over the raw logs and unpacked data for NewWithdraw events raised by the ContractsERC20 contract.
+type ContractsERC20NewWithdrawIterator struct {
+	Event *ContractsERC20NewWithdraw // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ContractsERC20NewWithdrawIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ContractsERC20NewWithdraw)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ContractsERC20NewWithdraw)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ContractsERC20NewWithdrawIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ContractsERC20NewWithdrawIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ContractsERC20NewWithdraw represents a NewWithdraw event raised by the ContractsERC20 contract.
+type ContractsERC20NewWithdraw struct {
+	User      common.Address
+	StateRoot [32]byte
+	Value     *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewWithdraw is a free log retrieval operation binding the contract event 0x33f28a9218883981815a14a0fd9f3d4e88e16b4d67e02bd72815c4dacfb5494f.
+//
+// Solidity: event New_withdraw(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) FilterNewWithdraw(opts *bind.FilterOpts) (*ContractsERC20NewWithdrawIterator, error) {
+
+	logs, sub, err := _ContractsERC20.contract.FilterLogs(opts, "New_withdraw")
+	if err != nil {
+		return nil, err
+	}
+	return &ContractsERC20NewWithdrawIterator{contract: _ContractsERC20.contract, event: "New_withdraw", logs: logs, sub: sub}, nil
+}
+
+// WatchNewWithdraw is a free log subscription operation binding the contract event 0x33f28a9218883981815a14a0fd9f3d4e88e16b4d67e02bd72815c4dacfb5494f.
+//
+// Solidity: event New_withdraw(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) WatchNewWithdraw(opts *bind.WatchOpts, sink chan<- *ContractsERC20NewWithdraw) (event.Subscription, error) {
+
+	logs, sub, err := _ContractsERC20.contract.WatchLogs(opts, "New_withdraw")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ContractsERC20NewWithdraw)
+				if err := _ContractsERC20.contract.UnpackLog(event, "New_withdraw", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewWithdraw is a log parse operation binding the contract event 0x33f28a9218883981815a14a0fd9f3d4e88e16b4d67e02bd72815c4dacfb5494f.
+//
+// Solidity: event New_withdraw(address user, bytes32 stateRoot, uint256 value)
+func (_ContractsERC20 *ContractsERC20Filterer) ParseNewWithdraw(log types.Log) (*ContractsERC20NewWithdraw, error) {
+	event := new(ContractsERC20NewWithdraw)
+	if err := _ContractsERC20.contract.UnpackLog(event, "New_withdraw", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+
+pragma solidity >=0.6.0 <=0.7.3;
+
+import { Optimistic_Rollups } from "./optimistic-rollups.sol";
+import { Lib_MerkleTrie } from "./Lib_MerkleTrie.sol";
+import { Lib_BytesUtils } from "./Lib_BytesUtils.sol";
+import { Lib_RLPReader } from "./Lib_RLPReader.sol";
+
+interface IERC20 {
+    function transfer(address recipient, uint256 amount) external returns (bool);
+    function transferFrom(address sender, address recipient, uint256 amount) external returns (bool);
+}
+
+//Token-aware variant, accounts are encoded as [nonce, balance, [token, balance]...] so ether withdraws and fraud proofs keep working
+contract Optimistic_Rollups_ERC20 is Optimistic_Rollups {
+
+    mapping(address => mapping(address => mapping(bytes32 => uint256))) private last_token_withdraws;
+    event New_Token_Deposit(address indexed user, address indexed token, bytes32 stateRoot, uint256 value);
+    event New_Token_Withdraw(address indexed user, address indexed token, bytes32 stateRoot, uint256 value);
+
+    constructor(
+        uint256 _lock_time,
+        uint256 _required_bond
+    ) Optimistic_Rollups(_lock_time, _required_bond) public {}
+
+    //Deposits _amount of _token, the user must have approved the contract before
+    function depositToken(address _token, uint256 _amount) external can_exit_optimism() {
+        require(_amount > 0, "EMPTY_DEPOSIT");
+        require(IERC20(_token).transferFrom(msg.sender, address(this), _amount), "TRANSFER_FAILED");
+        emit New_Token_Deposit(msg.sender, _token, stateRoot, _amount);
+    }
+
+    function withdrawToken(address _token, bytes calldata _key, bytes calldata _value, bytes memory _proof, bytes32 _root) external can_exit_optimism() {
+        require(_root == stateRoot, "NOT_VALID_PROOF");
+
+        //prevent double withdraw
+        require(last_token_withdraws[msg.sender][_token][stateRoot] == 0, "WITHDRAW_ALREADY_DONE");
+
+        require(Lib_MerkleTrie.verifyInclusionProof(_key,_value,_proof,_root) == true, "INVALID_ACCOUNT_PROOF");
+        require (Lib_BytesUtils.toAddress(_key,0) == msg.sender, "INVALID_WITHDRAW_REQUESTER");
+        uint256 tokenBalance = tokenBalanceOf(_value, _token);
+        require(tokenBalance > 0, "EMPTY_WITHDRAW");
+        last_token_withdraws[msg.sender][_token][stateRoot] = tokenBalance;
+        require(IERC20(_token).transfer(msg.sender, tokenBalance), "TRANSFER_FAILED");
+        emit New_Token_Withdraw(msg.sender, _token, stateRoot, tokenBalance);
+    }
+
+    //Claims the tokens burnt by the layer2 withdrawal transaction of msg.sender with the given nonce, the event user is the receipt account
+    function claimTokenWithdrawal(address _token, uint256 _nonce, bytes calldata _value, bytes memory _proof, bytes32 _root) external can_exit_optimism() {
+        require(_root == stateRoot, "NOT_VALID_PROOF");
+        address receipt = withdrawal_receipt(msg.sender, _nonce);
+        require(last_token_withdraws[receipt][_token][stateRoot] == 0, "WITHDRAW_ALREADY_DONE");
+        require(Lib_MerkleTrie.verifyInclusionProof(abi.encodePacked(receipt),_value,_proof,_root) == true, "INVALID_ACCOUNT_PROOF");
+        uint256 amount = tokenBalanceOf(_value, _token);
+        require(amount > 0, "EMPTY_WITHDRAW");
+        last_token_withdraws[receipt][_token][stateRoot] = amount;
+        require(IERC20(_token).transfer(msg.sender, amount), "TRANSFER_FAILED");
+        emit New_Token_Withdraw(receipt, _token, stateRoot, amount);
+    }
+
+    //Balance of _token in a RLP encoded account [nonce, balance, [token, balance]...]
+    function tokenBalanceOf(bytes memory _value, address _token) internal pure returns (uint256) {
+        Lib_RLPReader.RLPItem[] memory account = Lib_RLPReader.readList(_value);
+        for (uint256 i = 2; i < account.length; i++) {
+            Lib_RLPReader.RLPItem[] memory token = Lib_RLPReader.readList(account[i]);
+            if (Lib_BytesUtils.toAddress(Lib_RLPReader.readBytes(token[0]),0) == _token) {
+                return Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(token[1]));
+            }
+        }
+        return 0;
+    }
+}
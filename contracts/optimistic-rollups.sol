@@ -17,10 +17,15 @@ contract Optimistic_Rollups {
     mapping(address => address) public aggregators;
     mapping(bytes32 => bool) public valid_stateRoots;
     
+    //typed transaction whose ether payments are replayed by prove_fraud, any other typed transaction is skipped
+    uint8 constant MULTI_SEND_TX = 1;
+
     mapping(address => mapping(bytes32 => uint256)) private last_deposits;
     mapping(address => mapping(bytes32 => uint256)) private last_withdraws;
     event New_Deposit(address user, bytes32 stateRoot, uint256 value);
     event New_withdraw(address user, bytes32 stateRoot, uint256 value);
+    event New_Withdrawal_Claim(address user, address receipt, bytes32 stateRoot, uint256 value);
+    event New_Batch(address indexed submitter, bytes32 prevStateRoot, bytes32 stateRoot);
     event Fraud_Proved(address challenger);
     event Invalid_Proof(address challenger);
 
@@ -83,6 +88,28 @@ contract Optimistic_Rollups {
         msg.sender.transfer(accBalance);
         emit New_withdraw(msg.sender, stateRoot, accBalance);
     }
+
+    //Claims the ether burnt by the layer2 withdrawal transaction of msg.sender with the given nonce, its receipt account holds the amount
+    function claimWithdrawal(uint256 _nonce, bytes calldata _value, bytes memory _proof, bytes32 _root) external can_exit_optimism() {
+        require(_root == stateRoot, "NOT_VALID_PROOF");
+        address receipt = withdrawal_receipt(msg.sender, _nonce);
+
+        //prevent double claim, layer2 nodes empty the receipt balance on the next batch
+        require(last_withdraws[receipt][stateRoot] == 0, "WITHDRAW_ALREADY_DONE");
+
+        require(Lib_MerkleTrie.verifyInclusionProof(abi.encodePacked(receipt),_value,_proof,_root) == true, "INVALID_ACCOUNT_PROOF");
+        Lib_RLPReader.RLPItem[] memory account = Lib_RLPReader.readList(_value);
+        uint256 amount = Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(account[1]));
+        require(amount > 0, "EMPTY_WITHDRAW");
+        last_withdraws[receipt][stateRoot] = amount;
+        msg.sender.transfer(amount);
+        emit New_Withdrawal_Claim(msg.sender, receipt, stateRoot, amount);
+    }
+
+    //Layer2 account where the withdrawal transaction of _user with the given nonce leaves the burnt funds
+    function withdrawal_receipt(address _user, uint256 _nonce) public pure returns (address) {
+        return address(uint160(uint256(keccak256(abi.encodePacked(_user, _nonce)))));
+    }
     
     
 
@@ -102,6 +129,7 @@ contract Optimistic_Rollups {
         valid_stateRoots[prev_stateRoot] = true;
         last_batch_submitter = msg.sender;
         last_batch_time = block.timestamp;
+        emit New_Batch(msg.sender, prev_stateRoot, stateRoot);
     }
     
     //account_proof must contain a proof of the account balance for the previous stateRoot
@@ -123,30 +151,90 @@ contract Optimistic_Rollups {
 
         
         //Now we must verify the value of the account after the applyed batch
+        //Like layer2 nodes, each transaction charges its value (ether transfers) plus its fee to the sender, then pays the recipients and the fee to the batch submitter
+        bool isSubmitter = keccak256(abi.encodePacked(last_batch_submitter)) == accAddr;
         Lib_RLPReader.RLPItem[] memory ls = Lib_RLPReader.readList(_lastBatch);
         Lib_RLPReader.RLPItem[] memory transactions = Lib_RLPReader.readList(ls[2]);
         for (uint256 i = 0; i < transactions.length; i++) {
+            if (!is_list(transactions[i])) {
+                //typed transaction: type byte + RLP payload [value, gas, to, from, nonce, v, r, s, token, data]
+                bytes memory envelope = Lib_RLPReader.readBytes(transactions[i]);
+                if (uint8(envelope[0]) != MULTI_SEND_TX) continue;
+                Lib_RLPReader.RLPItem[] memory typed_data = Lib_RLPReader.readList(Lib_BytesUtils.slice(envelope, 1));
+                //token multi-sends only pay their fee in ether, like legacy token transfers
+                bool isEther = Lib_RLPReader.readAddress(typed_data[8]) == address(0);
+                uint256 total = 0;
+                if (isEther) {
+                    total = Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(typed_data[0]));
+                }
+                uint256 fee = Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(typed_data[1]));
+                if (keccak256(Lib_RLPReader.readBytes(typed_data[3])) == accAddr) {
+                    if (!can_pay(accBalance, total, fee)) {
+                        fraud_proved();
+                        return;
+                    }
+                    accBalance -= total + fee;
+                }
+                if (isEther) {
+                    //data is the RLP list of [to, value] payments
+                    Lib_RLPReader.RLPItem[] memory payments = Lib_RLPReader.readList(Lib_RLPReader.readBytes(typed_data[9]));
+                    for (uint256 j = 0; j < payments.length; j++) {
+                        Lib_RLPReader.RLPItem[] memory payment = Lib_RLPReader.readList(payments[j]);
+                        if (keccak256(Lib_RLPReader.readBytes(payment[0])) == accAddr) {
+                            accBalance += Lib_RLPReader.readUint256(payment[1]);
+                        }
+                    }
+                }
+                if (isSubmitter) accBalance += fee;
+                continue;
+            }
+            //legacy transaction: [value, gas, to, from, nonce, v, r, s, token], token transfers only pay their fee in ether
             Lib_RLPReader.RLPItem[] memory tx_data = Lib_RLPReader.readList(transactions[i]);
-            //if is the receipent
-            if (keccak256(Lib_RLPReader.readBytes(tx_data[2])) == accAddr) {
-                accBalance += Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(tx_data[1]));
-            } else if (keccak256(Lib_RLPReader.readBytes(tx_data[3])) == accAddr) {
-                uint256 txValue = abi.decode(Lib_RLPReader.readBytes(tx_data[1]), (uint256));
-                if (txValue > accBalance) {
-                    emit Fraud_Proved(msg.sender);
-                    delete aggregators[last_batch_submitter];
-                    stateRoot = prev_stateRoot;
-                    msg.sender.transfer(required_bond);
+            uint256 txValue = 0;
+            if (Lib_RLPReader.readAddress(tx_data[8]) == address(0)) {
+                txValue = Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(tx_data[0]));
+            }
+            uint256 txFee = Lib_BytesUtils.toUint256(Lib_RLPReader.readBytes(tx_data[1]));
+            if (keccak256(Lib_RLPReader.readBytes(tx_data[3])) == accAddr) {
+                if (!can_pay(accBalance, txValue, txFee)) {
+                    fraud_proved();
                     return;
                 }
-                accBalance -= txValue;
+                accBalance -= txValue + txFee;
             }
+            //if is the receipent
+            if (keccak256(Lib_RLPReader.readBytes(tx_data[2])) == accAddr) {
+                accBalance += txValue;
+            }
+            if (isSubmitter) accBalance += txFee;
         }
         emit Invalid_Proof(msg.sender);
 
         //if fraud is proved => change to the last apporved stateRoot and reward the prover
     }
     
+    //Checks _value + _fee <= _balance without overflowing
+    function can_pay(uint256 _balance, uint256 _value, uint256 _fee) internal pure returns (bool) {
+        return _value <= _balance && _fee <= _balance - _value;
+    }
+
+    //Reverts the last batch and rewards the prover with the submitter bond
+    function fraud_proved() internal {
+        emit Fraud_Proved(msg.sender);
+        delete aggregators[last_batch_submitter];
+        stateRoot = prev_stateRoot;
+        msg.sender.transfer(required_bond);
+    }
+
+    function is_list(Lib_RLPReader.RLPItem memory _item) internal pure returns (bool) {
+        uint256 ptr = _item.ptr;
+        uint256 prefix;
+        assembly {
+            prefix := byte(0, mload(ptr))
+        }
+        return prefix >= 0xc0;
+    }
+
     function remaining_proof_time() view public returns (uint256) {
         uint256 remaining = (last_batch_time + lock_time) - block.timestamp;
         if (remaining < 0) return 0;
@@ -0,0 +1,216 @@
+package optimisticrp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	levelDBCache   = 16 //megabytes
+	levelDBHandles = 16
+)
+
+//Key under which the last committed state root is stored
+var headStateKey = []byte("LastStateRoot")
+
+//Prefixes of the root <-> batch number index, batchRootPrefix + num (uint64 big endian) -> root and rootBatchPrefix + root -> num
+var (
+	batchRootPrefix = []byte("b")
+	rootBatchPrefix = []byte("r")
+)
+
+//Backend selects the Optimistic implementation of the accounts state
+type Backend string
+
+const (
+	//TrieBackend is the Merkle Patricia trie, its proofs are verified on-chain by Lib_MerkleTrie
+	TrieBackend Backend = "trie"
+	//SparseBackend is the sparse Merkle tree with fixed size, compressible proofs. It is kept in memory
+	SparseBackend Backend = "smt"
+)
+
+//Head is the last committed accounts state, the batch number is the position of the batch on chain
+type Head struct {
+	Root  common.Hash
+	Batch uint64
+}
+
+//Database holds the key-value store where the accounts trie nodes are committed
+type Database struct {
+	diskdb     ethdb.KeyValueStore
+	triedb     *trie.Database
+	proofCache *ProofCache
+	persistent bool //stored on disk, see NewLevelDB
+}
+
+func NewDatabase(diskdb ethdb.KeyValueStore) *Database {
+	return &Database{diskdb, trie.NewDatabase(diskdb), nil, false}
+}
+
+//NewMemoryDatabase returns a non persistent database, useful for tests and short lived nodes
+func NewMemoryDatabase() *Database {
+	return NewDatabase(memorydb.New())
+}
+
+//NewLevelDB opens (or creates) a LevelDB database in the given directory
+func NewLevelDB(path string) (*Database, error) {
+	diskdb, err := leveldb.New(path, levelDBCache, levelDBHandles, "optimisticrp")
+	if err != nil {
+		return nil, err
+	}
+	db := NewDatabase(diskdb)
+	db.persistent = true
+	return db, nil
+}
+
+//SetProofCache shares the proof cache between all the tries opened afterwards
+func (db *Database) SetProofCache(cache *ProofCache) {
+	db.proofCache = cache
+}
+
+func (db *Database) TrieDB() *trie.Database {
+	return db.triedb
+}
+
+//Head returns the last committed state, if nothing was committed yet an empty Head is returned
+func (db *Database) Head() (Head, error) {
+	return readHead(db.diskdb)
+}
+
+//Checkpoint returns the newest processed layer 1 block, nil if no node synced with this database yet
+func (db *Database) Checkpoint() (*Checkpoint, error) {
+	return readCheckpoint(db.diskdb)
+}
+
+//OpenTrie opens the accounts trie at the given root, the root nodes must have been committed before
+func (db *Database) OpenTrie(root common.Hash) (*OptimisticTrie, error) {
+	tr, err := trie.New(root, db.triedb)
+	if err != nil {
+		return nil, err
+	}
+	ot := newOptimisticTrie(tr, db.triedb)
+	ot.SetProofCache(db.proofCache)
+	return ot, nil
+}
+
+//OpenHead opens the accounts trie at the last committed root
+func (db *Database) OpenHead() (*OptimisticTrie, error) {
+	head, err := db.Head()
+	if err != nil {
+		return nil, err
+	}
+	return db.OpenTrie(head.Root)
+}
+
+//OpenState opens the accounts state with the given backend, the sparse Merkle tree always starts empty as it is not persisted
+//nor checkpointed, so it can not be opened on a LevelDB database: nodes would resync from genesis on every restart
+func (db *Database) OpenState(backend Backend) (Optimistic, error) {
+	switch backend {
+	case TrieBackend:
+		return db.OpenHead()
+	case SparseBackend:
+		if db.persistent {
+			return nil, fmt.Errorf("%s The %v backend is kept in memory, it can not be used with a datadir", OPR_BANNER, backend)
+		}
+		return NewSparseMerkleTree(), nil
+	default:
+		return nil, fmt.Errorf("%s Unknown state backend %v", OPR_BANNER, backend)
+	}
+}
+
+//StateAt opens a read-only view of the accounts state at any committed root
+func (db *Database) StateAt(root common.Hash) (*HistoricalState, error) {
+	num, err := db.BatchNumber(root)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &HistoricalState{tr, num}, nil
+}
+
+//StateAtBatch opens a read-only view of the accounts state right after the given batch was processed
+func (db *Database) StateAtBatch(num uint64) (*HistoricalState, error) {
+	root, err := db.BatchRoot(num)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &HistoricalState{tr, num}, nil
+}
+
+//BatchRoot returns the state root committed for the given batch number
+func (db *Database) BatchRoot(num uint64) (common.Hash, error) {
+	enc, err := db.diskdb.Get(batchRootKey(num))
+	if err != nil {
+		return common.Hash{}, &BatchNotFound{Batch: num}
+	}
+	return common.BytesToHash(enc), nil
+}
+
+//BatchNumber returns the batch number where the given state root was committed
+func (db *Database) BatchNumber(root common.Hash) (uint64, error) {
+	enc, err := db.diskdb.Get(rootBatchKey(root))
+	if err != nil || len(enc) != 8 {
+		return 0, &StateNotFound{Root: root}
+	}
+	return binary.BigEndian.Uint64(enc), nil
+}
+
+func (db *Database) Close() error {
+	return db.diskdb.Close()
+}
+
+func readHead(diskdb ethdb.KeyValueReader) (Head, error) {
+	var head Head
+	has, err := diskdb.Has(headStateKey)
+	if err != nil || !has {
+		return head, err
+	}
+	enc, err := diskdb.Get(headStateKey)
+	if err != nil {
+		return head, err
+	}
+	err = rlp.DecodeBytes(enc, &head)
+	return head, err
+}
+
+//writeHead stores the last committed state and indexes its root by batch number
+func writeHead(diskdb ethdb.KeyValueWriter, head Head) error {
+	enc, err := rlp.EncodeToBytes(head)
+	if err != nil {
+		return err
+	}
+	num := make([]byte, 8)
+	binary.BigEndian.PutUint64(num, head.Batch)
+	if err := diskdb.Put(batchRootKey(head.Batch), head.Root.Bytes()); err != nil {
+		return err
+	}
+	if err := diskdb.Put(rootBatchKey(head.Root), num); err != nil {
+		return err
+	}
+	return diskdb.Put(headStateKey, enc)
+}
+
+func batchRootKey(num uint64) []byte {
+	key := make([]byte, len(batchRootPrefix)+8)
+	copy(key, batchRootPrefix)
+	binary.BigEndian.PutUint64(key[len(batchRootPrefix):], num)
+	return key
+}
+
+func rootBatchKey(root common.Hash) []byte {
+	return append(append([]byte{}, rootBatchPrefix...), root.Bytes()...)
+}
@@ -0,0 +1,174 @@
+package optimisticrp
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//TxType identifies the kind of a transaction. Transfers (type 0) are encoded as the legacy RLP list, read by the contract fraud proof,
+//any other kind is a RLP byte string: type byte (0x01-0x7f) + RLP payload of the common fields and the kind specific Data.
+//Nodes decode every type, the transition package registers how each one is applied and rejects the unknown ones
+type TxType uint8
+
+const (
+	TransferTxType TxType = 0
+	//MaxTxType is the last type that fits the envelope, greater bytes would be read as RLP
+	MaxTxType TxType = 0x7f
+)
+
+type UnknownTxType struct {
+	Type TxType
+}
+
+func (e *UnknownTxType) Error() string {
+	return fmt.Sprintf("%s Unknown transaction type %d", OPR_BANNER, e.Type)
+}
+
+//legacyTx is the RLP list of a transfer
+type legacyTx struct {
+	Value   *big.Int
+	Gas     *big.Int
+	To      common.Address
+	From    common.Address
+	Nonce   uint64
+	V, R, S *big.Int
+	Token   common.Address
+}
+
+//typedTx is the payload of the typed envelope
+type typedTx struct {
+	Value   *big.Int
+	Gas     *big.Int
+	To      common.Address
+	From    common.Address
+	Nonce   uint64
+	V, R, S *big.Int
+	Token   common.Address
+	Data    []byte
+}
+
+func (tx *Transaction) typedFields() typedTx {
+	return typedTx{tx.Value, tx.Gas, tx.To, tx.From, tx.Nonce, tx.V, tx.R, tx.S, tx.Token, tx.Data}
+}
+
+func (tx Transaction) EncodeRLP(w io.Writer) error {
+	if tx.Type == TransferTxType {
+		return rlp.Encode(w, legacyTx{tx.Value, tx.Gas, tx.To, tx.From, tx.Nonce, tx.V, tx.R, tx.S, tx.Token})
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
+}
+
+func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		var dec legacyTx
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		*tx = Transaction{Value: dec.Value, Gas: dec.Gas, To: dec.To, From: dec.From, Nonce: dec.Nonce, V: dec.V, R: dec.R, S: dec.S, Token: dec.Token}
+		return nil
+	}
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	return tx.decodeTyped(b)
+}
+
+//decodeTyped decodes a type byte + RLP payload envelope
+func (tx *Transaction) decodeTyped(b []byte) error {
+	txType, payload, err := splitEnvelope(b)
+	if err != nil {
+		return err
+	}
+	var dec typedTx
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return err
+	}
+	*tx = Transaction{Value: dec.Value, Gas: dec.Gas, To: dec.To, From: dec.From, Nonce: dec.Nonce, V: dec.V, R: dec.R, S: dec.S, Token: dec.Token, Type: txType, Data: dec.Data}
+	return nil
+}
+
+//solidityLegacyTx and solidityTypedTx are legacyTx and typedTx with the amounts as 32 bytes words
+type solidityLegacyTx struct {
+	Value   []byte
+	Gas     []byte
+	To      common.Address
+	From    common.Address
+	Nonce   uint64
+	V, R, S *big.Int
+	Token   common.Address
+}
+
+type solidityTypedTx struct {
+	Value   []byte
+	Gas     []byte
+	To      common.Address
+	From    common.Address
+	Nonce   uint64
+	V, R, S *big.Int
+	Token   common.Address
+	Data    []byte
+}
+
+func (tx SolidityTransaction) EncodeRLP(w io.Writer) error {
+	if tx.Type == TransferTxType {
+		return rlp.Encode(w, solidityLegacyTx{tx.Value, tx.Gas, tx.To, tx.From, tx.Nonce, tx.V, tx.R, tx.S, tx.Token})
+	}
+	payload, err := rlp.EncodeToBytes(solidityTypedTx{tx.Value, tx.Gas, tx.To, tx.From, tx.Nonce, tx.V, tx.R, tx.S, tx.Token, tx.Data})
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, append([]byte{byte(tx.Type)}, payload...))
+}
+
+func (tx *SolidityTransaction) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		var dec solidityLegacyTx
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		*tx = SolidityTransaction{Value: dec.Value, Gas: dec.Gas, To: dec.To, From: dec.From, Nonce: dec.Nonce, V: dec.V, R: dec.R, S: dec.S, Token: dec.Token}
+		return nil
+	}
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	txType, payload, err := splitEnvelope(b)
+	if err != nil {
+		return err
+	}
+	var dec solidityTypedTx
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return err
+	}
+	*tx = SolidityTransaction{Value: dec.Value, Gas: dec.Gas, To: dec.To, From: dec.From, Nonce: dec.Nonce, V: dec.V, R: dec.R, S: dec.S, Token: dec.Token, Type: txType, Data: dec.Data}
+	return nil
+}
+
+func splitEnvelope(b []byte) (TxType, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, fmt.Errorf("%s Empty typed transaction", OPR_BANNER)
+	}
+	txType := TxType(b[0])
+	if txType == TransferTxType || txType > MaxTxType {
+		return 0, nil, fmt.Errorf("%s Invalid typed transaction envelope %d", OPR_BANNER, txType)
+	}
+	return txType, b[1:], nil
+}
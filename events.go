@@ -0,0 +1,151 @@
+package optimisticrp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//Event is an input of the rollup read from layer 1: BatchEvent, DepositEvent, WithdrawEvent or L1Block
+//Streams send them ordered by block number and log index, the L1Block of a block after its other events
+type Event interface {
+	Position() EventPosition
+	event()
+}
+
+//EventPosition locates the contract log that emitted an event, L1Block is positioned after every log of its block
+type EventPosition struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+//Before reports whether an event at p must be processed before one at other
+func (p EventPosition) Before(other EventPosition) bool {
+	return p.BlockNumber < other.BlockNumber || (p.BlockNumber == other.BlockNumber && p.LogIndex < other.LogIndex)
+}
+
+type BatchEvent struct {
+	EventPosition
+	Batch SolidityBatch
+}
+
+type DepositEvent struct {
+	EventPosition
+	Deposit Deposit
+}
+
+type WithdrawEvent struct {
+	EventPosition
+	Withdraw Withdraw
+}
+
+//L1Block is sent after the events of every layer 1 block that emitted some, and for the last block read
+//Nodes checkpoint each of them and check that consecutive blocks are linked by their parent hash
+type L1Block struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+}
+
+func (e BatchEvent) Position() EventPosition    { return e.EventPosition }
+func (e DepositEvent) Position() EventPosition  { return e.EventPosition }
+func (e WithdrawEvent) Position() EventPosition { return e.EventPosition }
+func (b L1Block) Position() EventPosition {
+	return EventPosition{BlockNumber: b.Number, BlockHash: b.Hash, LogIndex: ^uint(0)}
+}
+
+func (BatchEvent) event()    {}
+func (DepositEvent) event()  {}
+func (WithdrawEvent) event() {}
+func (L1Block) event()       {}
+
+type UnorderedEvent struct {
+	Prev, Next EventPosition
+}
+
+func (e *UnorderedEvent) Error() string {
+	return fmt.Sprintf("%s Event of block %d log %d received after block %d log %d", OPR_BANNER, e.Next.BlockNumber, e.Next.LogIndex, e.Prev.BlockNumber, e.Prev.LogIndex)
+}
+
+//SendEvent sends an event to a stream, it returns the context error if it is cancelled before the event is received
+func SendEvent(ctx context.Context, events chan<- Event, event Event) error {
+	select {
+	case events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//EventStream reads the events sent by a stream function (GetOnChainData, GetPendingDeposits) in order
+//Next returns false after the last event, the first error or once the context is cancelled, Err returns that error
+type EventStream struct {
+	events <-chan Event
+	errc   <-chan error
+	cancel context.CancelFunc
+	event  Event
+	last   *EventPosition
+	done   bool
+	err    error
+}
+
+//StreamOnChainData streams the on-chain data of the layer 1 blocks [from, to]
+func StreamOnChainData(ctx context.Context, contract OptimisticSContract, from, to uint64) *EventStream {
+	return NewEventStream(ctx, func(ctx context.Context, events chan<- Event) error {
+		return contract.GetOnChainData(ctx, from, to, events)
+	})
+}
+
+//NewEventStream runs stream in a goroutine, it must send the events to its channel and return the first error
+//The stream must be closed to release the goroutine if it is not read until the end
+func NewEventStream(ctx context.Context, stream func(context.Context, chan<- Event) error) *EventStream {
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(events)
+		errc <- stream(ctx, events)
+	}()
+	return &EventStream{events: events, errc: errc, cancel: cancel}
+}
+
+//Next waits for the next event, an event that is not after the previous one ends the stream with UnorderedEvent
+func (s *EventStream) Next() bool {
+	if s.done {
+		return false
+	}
+	event, ok := <-s.events
+	if !ok {
+		s.err = <-s.errc
+		s.Close()
+		return false
+	}
+	position := event.Position()
+	if s.last != nil && !s.last.Before(position) {
+		s.err = &UnorderedEvent{*s.last, position}
+		s.Close()
+		return false
+	}
+	s.last = &position
+	s.event = event
+	return true
+}
+
+func (s *EventStream) Event() Event {
+	return s.event
+}
+
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+//Close cancels the stream context and waits for the stream function to return
+func (s *EventStream) Close() {
+	s.done = true
+	s.cancel()
+	for range s.events {
+	}
+}
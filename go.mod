@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/ethereum/go-ethereum v1.9.25
+	github.com/hashicorp/golang-lru v0.5.4
 	github.com/pki-io/core v0.0.0-20170212075412-5f4467c73283
 	github.com/pki-io/ecies v0.0.0-20150213224233-7c0f4a9b18d9 // indirect
 	github.com/pulumi/pulumi/sdk v1.14.1 // indirect
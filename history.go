@@ -0,0 +1,37 @@
+package optimisticrp
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//HistoricalState is a read-only view of the accounts state committed at a past batch, it only reads accounts and proves them
+//It does not implement Optimistic, so it can not be given to the state transition or a node
+type HistoricalState struct {
+	tr    *OptimisticTrie
+	batch uint64
+}
+
+//Batch returns the batch number at which the state was committed
+func (hs *HistoricalState) Batch() uint64 {
+	return hs.batch
+}
+
+func (hs *HistoricalState) StateRoot() common.Hash {
+	return hs.tr.StateRoot()
+}
+
+func (hs *HistoricalState) GetAccount(address common.Address) (Account, error) {
+	return hs.tr.GetAccount(address)
+}
+
+func (hs *HistoricalState) NewProve(address common.Address) ([][]byte, error) {
+	return hs.tr.NewProve(address)
+}
+
+func (hs *HistoricalState) NewExclusionProof(address common.Address) ([][]byte, error) {
+	return hs.tr.NewExclusionProof(address)
+}
+
+func (hs *HistoricalState) NewMultiProof(addresses []common.Address) (*MultiProof, error) {
+	return hs.tr.NewMultiProof(addresses)
+}
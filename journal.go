@@ -0,0 +1,75 @@
+package optimisticrp
+
+import (
+	"fmt"
+	"sort"
+)
+
+//journalEntry keeps the value an account had before being modified, nil if the account did not exist
+type journalEntry struct {
+	key  []byte
+	prev []byte
+}
+
+type revision struct {
+	id           int
+	journalIndex int
+}
+
+//journal records every account modification so the trie can be reverted to a snapshot, the cost of a revert
+//is proportional to the number of modified accounts since the snapshot, not to the size of the trie
+type journal struct {
+	entries        []journalEntry
+	validRevisions []revision
+	nextRevisionId int
+}
+
+func newJournal() *journal {
+	return &journal{}
+}
+
+func (j *journal) append(key, prev []byte) {
+	j.entries = append(j.entries, journalEntry{key, prev})
+}
+
+func (j *journal) snapshot() int {
+	id := j.nextRevisionId
+	j.nextRevisionId++
+	j.validRevisions = append(j.validRevisions, revision{id, len(j.entries)})
+	return id
+}
+
+//revert returns the entries to undo (newest first) to get back to the given snapshot
+func (j *journal) revert(revid int) []journalEntry {
+	idx := sort.Search(len(j.validRevisions), func(i int) bool {
+		return j.validRevisions[i].id >= revid
+	})
+	if idx == len(j.validRevisions) || j.validRevisions[idx].id != revid {
+		panic(fmt.Errorf("revision id %v cannot be reverted", revid))
+	}
+	snapshot := j.validRevisions[idx].journalIndex
+	undo := make([]journalEntry, 0, len(j.entries)-snapshot)
+	for i := len(j.entries) - 1; i >= snapshot; i-- {
+		undo = append(undo, j.entries[i])
+	}
+	j.entries = j.entries[:snapshot]
+	j.validRevisions = j.validRevisions[:idx]
+	return undo
+}
+
+//discard invalidates the given snapshot and the newer ones, the entries are dropped once no snapshot can revert them
+func (j *journal) discard(revid int) {
+	idx := sort.Search(len(j.validRevisions), func(i int) bool {
+		return j.validRevisions[i].id >= revid
+	})
+	j.validRevisions = j.validRevisions[:idx]
+	if len(j.validRevisions) == 0 {
+		j.entries = nil
+	}
+}
+
+//reset drops all the entries and invalidates every snapshot taken so far
+func (j *journal) reset() {
+	j.entries = nil
+	j.validRevisions = nil
+}
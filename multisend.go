@@ -0,0 +1,80 @@
+package optimisticrp
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//MultiSendTxType pays several recipients from one sender with a single nonce and signature, all the payments are applied or none
+const MultiSendTxType TxType = 1
+
+//Payments of a multi-send transaction above this limit are rejected
+const MaxPayments = 1024
+
+//Payment is one recipient of a multi-send transaction, the Data of the transaction is the RLP list of its payments
+type Payment struct {
+	To    common.Address
+	Value *big.Int
+}
+
+type InvalidMultiSend struct {
+	Reason string
+}
+
+func (e *InvalidMultiSend) Error() string {
+	return fmt.Sprintf("%s Invalid multi-send transaction: %s", OPR_BANNER, e.Reason)
+}
+
+//NewMultiSendTx builds an unsigned multi-send of the given asset, Value is the total paid to the recipients
+func NewMultiSendTx(from, asset common.Address, payments []Payment, gas *big.Int, nonce uint64) (*Transaction, error) {
+	data, err := rlp.EncodeToBytes(payments)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, payment := range payments {
+		total.Add(total, payment.Value)
+	}
+	return &Transaction{Type: MultiSendTxType, From: from, Token: asset, Value: total, Gas: gas, Nonce: nonce, Data: data}, nil
+}
+
+//Payments decodes the payments of a multi-send transaction. They must be canonically encoded, between 1 and MaxPayments, and add up to the transaction Value
+func (tx *Transaction) Payments() ([]Payment, error) {
+	if tx.Type != MultiSendTxType {
+		return nil, &InvalidMultiSend{fmt.Sprintf("transaction type %d", tx.Type)}
+	}
+	payments, err := decodePayments(tx.Data)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, payment := range payments {
+		total.Add(total, payment.Value)
+	}
+	if tx.Value == nil || tx.Value.Cmp(total) != 0 {
+		return nil, &InvalidMultiSend{fmt.Sprintf("value %v is not the payments total %v", tx.Value, total)}
+	}
+	if tx.To != (common.Address{}) {
+		return nil, &InvalidMultiSend{"recipient outside the payments"}
+	}
+	return payments, nil
+}
+
+func decodePayments(data []byte) ([]Payment, error) {
+	var payments []Payment
+	if err := rlp.DecodeBytes(data, &payments); err != nil {
+		return nil, &InvalidMultiSend{err.Error()}
+	}
+	if len(payments) == 0 || len(payments) > MaxPayments {
+		return nil, &InvalidMultiSend{fmt.Sprintf("%d payments", len(payments))}
+	}
+	//the compact batch encoding rebuilds Data from the payments, so only one encoding is valid
+	if enc, _ := rlp.EncodeToBytes(payments); !bytes.Equal(enc, data) {
+		return nil, &InvalidMultiSend{"non canonical payments"}
+	}
+	return payments, nil
+}
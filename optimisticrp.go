@@ -1,12 +1,7 @@
 package optimisticrp
 
 import (
-	"bytes"
-	"fmt"
-	"math/big"
-
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -14,6 +9,9 @@ import (
 
 type OptimisticTrie struct {
 	*trie.Trie
+	db         *trie.Database
+	journal    *journal
+	proofCache *ProofCache
 }
 
 func NewTrie(triedb *trie.Database) (*OptimisticTrie, error) {
@@ -21,7 +19,11 @@ func NewTrie(triedb *trie.Database) (*OptimisticTrie, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &OptimisticTrie{tr}, nil
+	return newOptimisticTrie(tr, triedb), nil
+}
+
+func newOptimisticTrie(tr *trie.Trie, triedb *trie.Database) *OptimisticTrie {
+	return &OptimisticTrie{tr, triedb, newJournal(), nil}
 }
 
 func (ot *OptimisticTrie) GetAccount(address common.Address) (Account, error) {
@@ -44,110 +46,108 @@ func (ot *OptimisticTrie) UpdateAccount(address common.Address, acc Account) com
 		panic(err)
 	}
 	//acc.Balance = new(big.Int).SetUint64(0e+18)
+	ot.journal.append(address.Bytes(), ot.Get(address.Bytes()))
 	ot.Update(address.Bytes(), val)
 	return ot.Hash()
 }
 
-func (ot *OptimisticTrie) StateRoot() common.Hash {
-	return ot.Hash()
+//Snapshot returns an identifier of the current accounts state that can be later reverted to
+func (ot *OptimisticTrie) Snapshot() int {
+	return ot.journal.snapshot()
 }
 
-func (ot *OptimisticTrie) NewProve(address common.Address) ([][]byte, error) {
-	fBytes := ot.Get(address.Bytes())
-	if len(fBytes) == 0 {
-		return nil, &AccountNotFound{address}
-	}
-	it := trie.NewIterator(ot.NodeIterator(nil))
-	accounts := 0
-	for it.Next() {
-		accounts += 1
-	}
-	//log.Printf("Number of accounts in Trie: %v\n", accounts)
-	proof := memorydb.New()
-	formatProof := [][]byte{}
-	if it := trie.NewIterator(ot.NodeIterator(address.Bytes())); it.Next() && bytes.Equal(address.Bytes(), it.Key) {
-		for _, p := range it.Prove() {
-			formatProof = append(formatProof, p)
-			proof.Put(crypto.Keccak256(p), p)
+//RevertToSnapshot undoes all the account updates done since the given snapshot was taken
+func (ot *OptimisticTrie) RevertToSnapshot(revid int) {
+	for _, entry := range ot.journal.revert(revid) {
+		if entry.prev == nil {
+			ot.Delete(entry.key)
+		} else {
+			ot.Update(entry.key, entry.prev)
 		}
 	}
+}
+
+func (ot *OptimisticTrie) DiscardSnapshot(revid int) {
+	ot.journal.discard(revid)
+}
+
+func (ot *OptimisticTrie) StateRoot() common.Hash {
+	return ot.Hash()
+}
 
-	toSend := make([][]byte, 4)
-	//key
-	toSend[0] = address.Bytes()
-	//value
-	toSend[1] = fBytes
-	//root
-	toSend[3] = ot.Hash().Bytes()
-	rlpProof, err := rlp.EncodeToBytes(formatProof)
+//CommitBatch writes all the trie nodes to the underlying key-value store and records the resulting root as the last committed state
+//Snapshots taken before the commit can not be reverted anymore
+func (ot *OptimisticTrie) CommitBatch(batch uint64) (common.Hash, error) {
+	root, err := ot.Commit(nil)
 	if err != nil {
-		return nil, err
-	}
-	//rlp proof for onchain data https://github.com/ethereum-optimism/contracts/blob/c39fcc40aec235511a5a161c3e33a6d3bd24221c/test/helpers/trie/trie-test-generator.ts#L170
-	toSend[2] = rlpProof
-	val, err := trie.VerifyProof(ot.StateRoot(), address.Bytes(), proof)
-	if !bytes.Equal(val, fBytes) {
-		return nil, fmt.Errorf("Verified value mismatch for key %x: have %x, want %x", address, val, fBytes)
+		return common.Hash{}, err
 	}
-	return toSend, err
-}
-
-//Additional helpers not linked to interface so you can use them as you wish
-func (ot *OptimisticTrie) AddFunds(account common.Address, value *big.Int) error {
-	acc, err := ot.GetAccount(account)
-	switch err.(type) {
-	case nil:
-	case *AccountNotFound:
-		newAcc := Account{Balance: value, Nonce: 0}
-		ot.UpdateAccount(account, newAcc)
-		return nil
-	default:
-		return err
+	ot.journal.reset()
+	if err := ot.db.Commit(root, false, nil); err != nil {
+		return common.Hash{}, err
 	}
-	acc.Balance.Add(acc.Balance, value)
-	ot.UpdateAccount(account, acc)
-	return nil
+	return root, writeHead(ot.db.DiskDB(), Head{root, batch})
+}
+
+func (ot *OptimisticTrie) Head() (Head, error) {
+	return readHead(ot.db.DiskDB())
 }
 
-func (ot *OptimisticTrie) RemoveFunds(account common.Address, value *big.Int) error {
-	acc, err := ot.GetAccount(account)
-	switch err.(type) {
-	case nil:
-	case *AccountNotFound:
-		newAcc := Account{Balance: value, Nonce: 0}
-		ot.UpdateAccount(account, newAcc)
-		return nil
-	default:
+//Checkpoint returns the newest checkpoint, nil if there is none
+func (ot *OptimisticTrie) Checkpoint() (*Checkpoint, error) {
+	return readCheckpoint(ot.db.DiskDB())
+}
+
+func (ot *OptimisticTrie) Checkpoints() ([]*Checkpoint, error) {
+	return readCheckpoints(ot.db.DiskDB())
+}
+
+//WriteCheckpoint stores the newest processed layer 1 block, its StateRoot must have been committed
+func (ot *OptimisticTrie) WriteCheckpoint(checkpoint *Checkpoint) error {
+	return writeCheckpoint(ot.db.DiskDB(), checkpoint)
+}
+
+//ResetTo opens the trie at a committed root and invalidates all the snapshots
+func (ot *OptimisticTrie) ResetTo(root common.Hash) error {
+	tr, err := trie.New(root, ot.db)
+	if err != nil {
 		return err
 	}
-	acc.Balance.Sub(acc.Balance, value)
-	ot.UpdateAccount(account, acc)
+	ot.Trie = tr
+	ot.journal.reset()
 	return nil
 }
 
-func (ot *OptimisticTrie) ProcessTx(transaction Transaction) (common.Hash, error) {
-	fromAcc, err := ot.GetAccount(transaction.From)
-	if err != nil {
-		return common.Hash{}, err
+//NewProve returns the account inclusion proof: key, value, rlp proof (Lib_MerkleTrie format) and root
+//Only the nodes on the path to the account are visited, proofs are served from the proof cache if one is set
+func (ot *OptimisticTrie) NewProve(address common.Address) ([][]byte, error) {
+	fBytes := ot.Get(address.Bytes())
+	if len(fBytes) == 0 {
+		return nil, &AccountNotFound{address}
 	}
-	toAcc, err := ot.GetAccount(transaction.To)
-	switch err.(type) {
-	case nil:
-	case *AccountNotFound:
-		toAcc = Account{Balance: new(big.Int).SetUint64(0), Nonce: 0}
-		ot.UpdateAccount(transaction.To, toAcc)
-	default:
-		return common.Hash{}, err
+	root := ot.Hash()
+	if toSend, ok := ot.proofCache.get(root, address); ok {
+		return toSend, nil
 	}
-	//tx Value must be higher than the account balance (fee are not included)
-	if fromAcc.Balance.Cmp(transaction.Value) == -1 {
-		return common.Hash{}, &InvalidBalance{transaction.From, fromAcc.Balance}
+	//rlp proof for onchain data https://github.com/ethereum-optimism/contracts/blob/c39fcc40aec235511a5a161c3e33a6d3bd24221c/test/helpers/trie/trie-test-generator.ts#L170
+	rlpProof, err := ot.encodedProof(address.Bytes())
+	if err != nil {
+		return nil, err
 	}
-	fromAcc.Balance.Sub(fromAcc.Balance, transaction.Value)
-	toAcc.Balance.Add(toAcc.Balance, transaction.Value)
-	fromAcc.Nonce++
-	ot.UpdateAccount(transaction.From, fromAcc)
-	return ot.UpdateAccount(transaction.To, toAcc), nil
+	toSend := [][]byte{address.Bytes(), fBytes, rlpProof, root.Bytes()}
+	ot.proofCache.add(root, address, toSend)
+	return toSend, nil
+}
+
+//SetProofCache makes NewProve keep its last proofs in the given cache, it can be shared by tries of the same database
+func (ot *OptimisticTrie) SetProofCache(cache *ProofCache) {
+	ot.proofCache = cache
+}
+
+//Reset empties the trie and invalidates all the snapshots, committed nodes are kept in the database
+func (ot *OptimisticTrie) Reset() {
+	ot.Trie.Reset()
+	ot.journal.reset()
 }
 
 func (ot *OptimisticTrie) Copy() (*OptimisticTrie, error) {
@@ -163,5 +163,5 @@ func (ot *OptimisticTrie) Copy() (*OptimisticTrie, error) {
 	for it.Next() {
 		tr.Update(it.Key, it.Value)
 	}
-	return &OptimisticTrie{tr}, nil
+	return newOptimisticTrie(tr, triedb), nil
 }
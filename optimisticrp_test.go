@@ -1,10 +1,13 @@
 package optimisticrp
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math/big"
+	"os"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -144,3 +147,325 @@ func TestUpdateAccount(t *testing.T) {
 	}
 
 }
+
+func TestCommitBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "optimisticrp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := db.OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdateAccount(address1, acc1)
+	tr.UpdateAccount(address2, acc2)
+	root, err := tr.CommitBatch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	head, err := db.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Root != root || head.Batch != 1 {
+		t.Errorf("Head = %v; want %v at batch 1", head, root)
+	}
+	reopened, err := db.OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.StateRoot() != root {
+		t.Errorf("StateRoot = %v; want %v", reopened.StateRoot(), root)
+	}
+	got, err := reopened.GetAccount(address2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Nonce != acc2.Nonce || got.Balance.Cmp(acc2.Balance) != 0 {
+		t.Errorf("Account = %v; want %v", got, acc2)
+	}
+}
+
+func TestWriteCheckpoint(t *testing.T) {
+	db := NewMemoryDatabase()
+	tr, err := db.OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for block := uint64(1); block <= MaxCheckpoints+2; block++ {
+		if err := tr.WriteCheckpoint(&Checkpoint{Block: block, Deposits: []Deposit{{From: address1, Value: big.NewInt(1)}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkpoints, err := tr.Checkpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != MaxCheckpoints || checkpoints[0].Block != 3 || len(checkpoints[0].Deposits) != 1 {
+		t.Fatalf("Checkpoints = %d from block %d; want %d from block 3", len(checkpoints), checkpoints[0].Block, MaxCheckpoints)
+	}
+	//a checkpoint of an earlier block replaces the later ones
+	if err := tr.WriteCheckpoint(&Checkpoint{Block: 10}); err != nil {
+		t.Fatal(err)
+	}
+	newest, err := db.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints, _ := tr.Checkpoints(); newest.Block != 10 || len(checkpoints) != 8 {
+		t.Errorf("Newest checkpoint = %d of %d; want 10 of 8", newest.Block, len(checkpoints))
+	}
+}
+
+func TestStateAtBatch(t *testing.T) {
+	db := NewMemoryDatabase()
+	tr, err := db.OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdateAccount(address1, acc1)
+	root1, err := tr.CommitBatch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdateAccount(address1, Account{Balance: new(big.Int).SetUint64(4e+18), Nonce: 1})
+	if _, err := tr.CommitBatch(2); err != nil {
+		t.Fatal(err)
+	}
+	state, err := db.StateAtBatch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.StateRoot() != root1 {
+		t.Errorf("StateRoot = %v; want %v", state.StateRoot(), root1)
+	}
+	got, err := state.GetAccount(address1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Balance.Cmp(acc1.Balance) != 0 {
+		t.Errorf("Balance at batch 1 = %v; want %v", got.Balance, acc1.Balance)
+	}
+	if _, err := state.NewProve(address1); err != nil {
+		t.Error(err)
+	}
+	if _, ok := interface{}(state).(Optimistic); ok {
+		t.Error("A past state must not be modifiable")
+	}
+	byRoot, err := db.StateAt(root1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byRoot.Batch() != 1 {
+		t.Errorf("Batch = %d; want %d", byRoot.Batch(), 1)
+	}
+	if _, err := db.StateAtBatch(3); err == nil {
+		t.Errorf("Opened a state for a batch that was never committed")
+	}
+}
+
+func TestRevertToSnapshot(t *testing.T) {
+	tr, err := NewMemoryDatabase().OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdateAccount(address1, acc1)
+	initialRoot := tr.StateRoot()
+	snapshot := tr.Snapshot()
+	tr.UpdateAccount(address1, Account{Balance: new(big.Int).SetUint64(2e+18), Nonce: 1})
+	tr.UpdateAccount(address2, acc2)
+	inner := tr.Snapshot()
+	tr.UpdateAccount(address3, acc3)
+	tr.RevertToSnapshot(inner)
+	if _, err := tr.GetAccount(address3); err == nil {
+		t.Errorf("Account created after the snapshot was not reverted")
+	}
+	if _, err := tr.GetAccount(address2); err != nil {
+		t.Errorf("Account created before the snapshot was reverted")
+	}
+	tr.RevertToSnapshot(snapshot)
+	if tr.StateRoot() != initialRoot {
+		t.Errorf("StateRoot = %v; want %v", tr.StateRoot(), initialRoot)
+	}
+	got, err := tr.GetAccount(address1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Nonce != acc1.Nonce || got.Balance.Cmp(acc1.Balance) != 0 {
+		t.Errorf("Account = %v; want %v", got, acc1)
+	}
+}
+
+func TestExclusionProof(t *testing.T) {
+	tr, err := NewMemoryDatabase().OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []common.Address{address1, address2, address3, address4} {
+		tr.UpdateAccount(addr, acc1)
+	}
+	toSend, err := tr.NewExclusionProof(address5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyExclusionProof(tr.StateRoot(), address5, toSend[2]); err != nil {
+		t.Errorf("Exclusion proof of %v must be valid: %v", address5, err)
+	}
+	if err := VerifyExclusionProof(common.Hash{1}, address5, toSend[2]); err == nil {
+		t.Errorf("Exclusion proof must not be valid for another root")
+	}
+	if _, err := tr.NewExclusionProof(address1); err == nil {
+		t.Errorf("Exclusion proof of an existing account must fail")
+	}
+	inclusion, err := tr.NewProve(address1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyExclusionProof(tr.StateRoot(), address1, inclusion[2]); err == nil {
+		t.Errorf("Inclusion proof must not verify the absence of %v", address1)
+	}
+	acc, err := VerifyAccountProof(tr.StateRoot(), address1, inclusion[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance.Cmp(acc1.Balance) != 0 {
+		t.Errorf("Proven account = %v; want %v", acc, acc1)
+	}
+}
+
+func TestMultiProof(t *testing.T) {
+	tr, err := NewMemoryDatabase().OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses := []common.Address{address1, address2, address3, address4}
+	for _, addr := range addresses {
+		tr.UpdateAccount(addr, acc2)
+	}
+	for i := 0; i < 200; i++ {
+		tr.UpdateAccount(randomAddress(), acc1)
+	}
+	mp, err := tr.NewMultiProof(append(addresses, address5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := mp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnMarshalMultiProof(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := VerifyMultiProof(tr.StateRoot(), decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range addresses {
+		if acc := accounts[addr]; acc == nil || acc.Nonce != acc2.Nonce {
+			t.Errorf("Account %v = %v; want %v", addr, acc, acc2)
+		}
+	}
+	if acc, ok := accounts[address5]; !ok || acc != nil {
+		t.Errorf("Absent account %v must be proven as nil", address5)
+	}
+	//the root node is shared by all the keys
+	separate := 0
+	for _, addr := range addresses {
+		toSend, err := tr.NewProve(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		separate += len(toSend[2])
+	}
+	if len(enc) >= separate {
+		t.Errorf("Multiproof size = %v; must be smaller than separate proofs (%v)", len(enc), separate)
+	}
+	decoded.Nodes = decoded.Nodes[1:]
+	if _, err := VerifyMultiProof(tr.StateRoot(), decoded); err == nil {
+		t.Errorf("Multiproof without its root node must not be valid")
+	}
+}
+
+func TestProofCache(t *testing.T) {
+	cache, err := NewProofCache(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewMemoryDatabase()
+	db.SetProofCache(cache)
+	tr, err := db.OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.UpdateAccount(address1, acc1)
+	first, err := tr.NewProve(address1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.cache.Len() != 1 {
+		t.Errorf("Cached proofs = %v; want 1", cache.cache.Len())
+	}
+	tr.UpdateAccount(address2, acc2)
+	second, err := tr.NewProve(address1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first[3], second[3]) || cache.cache.Len() != 2 {
+		t.Errorf("Proofs of a new root must not be served from the cache")
+	}
+	if _, err := VerifyAccountProof(tr.StateRoot(), address1, second[2]); err != nil {
+		t.Error(err)
+	}
+}
+
+func benchmarkNewProve(b *testing.B, accounts int, cache *ProofCache) {
+	tr, err := NewMemoryDatabase().OpenHead()
+	if err != nil {
+		b.Fatal(err)
+	}
+	tr.SetProofCache(cache)
+	addresses := make([]common.Address, accounts)
+	for i := range addresses {
+		addresses[i] = common.BytesToAddress(crypto.Keccak256(big.NewInt(int64(i)).Bytes()))
+		tr.UpdateAccount(addresses[i], acc1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tr.NewProve(addresses[i%accounts]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewProve(b *testing.B) {
+	for _, accounts := range []int{100, 1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			benchmarkNewProve(b, accounts, nil)
+		})
+	}
+}
+
+func BenchmarkNewProveCached(b *testing.B) {
+	for _, accounts := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			cache, err := NewProofCache(accounts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkNewProve(b, accounts, cache)
+		})
+	}
+}
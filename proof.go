@@ -0,0 +1,242 @@
+package optimisticrp
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+//proofList collects the trie nodes from the root to the key, the order Lib_MerkleTrie expects
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+//encodedProof returns the RLP list of the trie nodes on the path to key, it is valid for both present and absent keys
+func (ot *OptimisticTrie) encodedProof(key []byte) ([]byte, error) {
+	var proof proofList
+	if err := ot.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes([][]byte(proof))
+}
+
+//NewExclusionProof proves that the address has no account at the current state root, it has the same layout than NewProve with an empty value:
+//key, nil, rlp proof and root. Lib_MerkleTrie.verifyExclusionProof checks it on-chain
+func (ot *OptimisticTrie) NewExclusionProof(address common.Address) ([][]byte, error) {
+	if fBytes := ot.Get(address.Bytes()); len(fBytes) != 0 {
+		return nil, &AccountFound{address}
+	}
+	rlpProof, err := ot.encodedProof(address.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	toSend := [][]byte{address.Bytes(), nil, rlpProof, ot.Hash().Bytes()}
+	if err := VerifyExclusionProof(ot.Hash(), address, rlpProof); err != nil {
+		return nil, err
+	}
+	return toSend, nil
+}
+
+//VerifyAccountProof checks offline an inclusion proof generated by NewProve and returns the proven account
+func VerifyAccountProof(root common.Hash, address common.Address, rlpProof []byte) (Account, error) {
+	proofDB, err := decodeProof(rlpProof)
+	if err != nil {
+		return Account{}, err
+	}
+	val, err := trie.VerifyProof(root, address.Bytes(), proofDB)
+	if err != nil || len(val) == 0 {
+		return Account{}, &InvalidProof{address, root}
+	}
+	var acc SolidityAccount
+	if err := rlp.DecodeBytes(val, &acc); err != nil {
+		return Account{}, err
+	}
+	return acc.ToGolangFormat()
+}
+
+//NewWithdrawalProof proves the receipt account of the withdrawal transaction of from with the given nonce, it has the NewProve layout
+//and is claimed on-chain by from with claimWithdrawal (ether) or claimTokenWithdrawal (tokens)
+func NewWithdrawalProof(state Optimistic, from common.Address, nonce uint64) ([][]byte, error) {
+	return state.NewProve(WithdrawalReceipt(from, nonce))
+}
+
+//VerifyWithdrawalProof checks offline a withdrawal proof and returns the claimable amount of asset
+func VerifyWithdrawalProof(root common.Hash, from common.Address, nonce uint64, asset common.Address, rlpProof []byte) (*big.Int, error) {
+	acc, err := VerifyAccountProof(root, WithdrawalReceipt(from, nonce), rlpProof)
+	if err != nil {
+		return nil, err
+	}
+	return acc.BalanceOf(asset), nil
+}
+
+//VerifyExclusionProof checks offline that the proof shows the address has no account at the given root
+//An empty state root excludes every address
+func VerifyExclusionProof(root common.Hash, address common.Address, rlpProof []byte) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	proofDB, err := decodeProof(rlpProof)
+	if err != nil {
+		return err
+	}
+	val, err := trie.VerifyProof(root, address.Bytes(), proofDB)
+	if err != nil || len(val) != 0 {
+		return &InvalidProof{address, root}
+	}
+	return nil
+}
+
+//decodeProof indexes the RLP list of proof nodes by their hash
+func decodeProof(rlpProof []byte) (*memorydb.Database, error) {
+	var nodes [][]byte
+	if err := rlp.DecodeBytes(rlpProof, &nodes); err != nil {
+		return nil, err
+	}
+	return newProofDB(nodes), nil
+}
+
+func newProofDB(nodes [][]byte) *memorydb.Database {
+	proofDB := memorydb.New()
+	for _, node := range nodes {
+		proofDB.Put(crypto.Keccak256(node), node)
+	}
+	return proofDB
+}
+
+//MultiProof proves several accounts, or their absence, against the same state root. Trie nodes shared by the paths are stored once
+type MultiProof struct {
+	Root  common.Hash
+	Keys  []common.Address
+	Nodes [][]byte
+}
+
+//nodeSet collects proof nodes keeping the first occurrence of each one
+type nodeSet struct {
+	seen  map[common.Hash]struct{}
+	nodes [][]byte
+}
+
+func (ns *nodeSet) Put(key []byte, value []byte) error {
+	hash := common.BytesToHash(key)
+	if _, ok := ns.seen[hash]; !ok {
+		ns.seen[hash] = struct{}{}
+		ns.nodes = append(ns.nodes, value)
+	}
+	return nil
+}
+
+func (ns *nodeSet) Delete(key []byte) error {
+	panic("not supported")
+}
+
+//NewMultiProof proves all the given addresses at the current state root, absent addresses get an exclusion proof
+func (ot *OptimisticTrie) NewMultiProof(addresses []common.Address) (*MultiProof, error) {
+	set := &nodeSet{seen: make(map[common.Hash]struct{})}
+	for _, address := range addresses {
+		if err := ot.Prove(address.Bytes(), 0, set); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]common.Address, len(addresses))
+	copy(keys, addresses)
+	return &MultiProof{Root: ot.Hash(), Keys: keys, Nodes: set.nodes}, nil
+}
+
+func (mp *MultiProof) MarshalBinary() ([]byte, error) {
+	return rlp.EncodeToBytes(mp)
+}
+
+func UnMarshalMultiProof(b []byte) (*MultiProof, error) {
+	var mp MultiProof
+	err := rlp.DecodeBytes(b, &mp)
+	return &mp, err
+}
+
+//VerifyMultiProof checks offline every key of the bundle against root, the returned map has a nil account for the proven absent keys
+func VerifyMultiProof(root common.Hash, mp *MultiProof) (map[common.Address]*Account, error) {
+	if mp.Root != root {
+		return nil, &InvalidProof{Root: root}
+	}
+	proofDB := newProofDB(mp.Nodes)
+	accounts := make(map[common.Address]*Account, len(mp.Keys))
+	for _, address := range mp.Keys {
+		if root == types.EmptyRootHash {
+			accounts[address] = nil
+			continue
+		}
+		val, err := trie.VerifyProof(root, address.Bytes(), proofDB)
+		if err != nil {
+			return nil, &InvalidProof{address, root}
+		}
+		if len(val) == 0 {
+			accounts[address] = nil
+			continue
+		}
+		var sacc SolidityAccount
+		if err := rlp.DecodeBytes(val, &sacc); err != nil {
+			return nil, err
+		}
+		acc, err := sacc.ToGolangFormat()
+		if err != nil {
+			return nil, err
+		}
+		accounts[address] = &acc
+	}
+	return accounts, nil
+}
+
+type proofCacheKey struct {
+	root    common.Hash
+	address common.Address
+}
+
+//ProofCache is a LRU cache of account proofs, entries are keyed by (root, address) so they never get stale
+//A nil *ProofCache is a valid disabled cache
+type ProofCache struct {
+	cache *lru.Cache
+}
+
+func NewProofCache(size int) (*ProofCache, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &ProofCache{cache}, nil
+}
+
+func (pc *ProofCache) get(root common.Hash, address common.Address) ([][]byte, bool) {
+	if pc == nil {
+		return nil, false
+	}
+	cached, ok := pc.cache.Get(proofCacheKey{root, address})
+	if !ok {
+		return nil, false
+	}
+	return append([][]byte{}, cached.([][]byte)...), true
+}
+
+func (pc *ProofCache) add(root common.Hash, address common.Address, proof [][]byte) {
+	if pc == nil {
+		return
+	}
+	pc.cache.Add(proofCacheKey{root, address}, append([][]byte{}, proof...))
+}
+
+//OpenMultiProof returns an in-memory trie at the multiproof root holding only its nodes, the proven accounts can be read and updated
+//Other accounts are not available, the caller must check the multiproof covers every account it touches
+func OpenMultiProof(mp *MultiProof) (*OptimisticTrie, error) {
+	return NewDatabase(newProofDB(mp.Nodes)).OpenTrie(mp.Root)
+}
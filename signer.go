@@ -0,0 +1,126 @@
+package optimisticrp
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+
+//RollupSigner signs and recovers the sender of transactions using secp256k1 keys. The signed hash is domain separated by
+//the L1 chain id and the rollup contract address, so a signature can not be replayed on another rollup deployment
+type RollupSigner struct {
+	chainID  *big.Int
+	contract common.Address
+}
+
+func NewRollupSigner(chainID *big.Int, contract common.Address) RollupSigner {
+	return RollupSigner{new(big.Int).Set(chainID), contract}
+}
+
+//ContractSigner returns the signer of the rollup deployed at the given contract
+func ContractSigner(contract OptimisticSContract) (RollupSigner, error) {
+	chainID, err := contract.ChainID()
+	if err != nil {
+		return RollupSigner{}, err
+	}
+	return NewRollupSigner(chainID, contract.OriAddr()), nil
+}
+
+func (rs RollupSigner) ChainID() *big.Int {
+	return new(big.Int).Set(rs.chainID)
+}
+
+func (rs RollupSigner) Contract() common.Address {
+	return rs.contract
+}
+
+func (rs RollupSigner) SignatureValues(sig []byte) (r, s, v *big.Int, err error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, nil, nil, fmt.Errorf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	r = new(big.Int).SetBytes(sig[:32])
+	s = new(big.Int).SetBytes(sig[32:64])
+	v = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	return r, s, v, nil
+}
+
+//Hash returns the keccak256 hash of the RLP encoded unsigned transaction fields prefixed by the chain id and contract address
+//Typed transactions also sign their type and Data, so a signature is only valid for one kind
+func (rs RollupSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type != TransferTxType {
+		return rlpHash([]interface{}{
+			rs.chainID,
+			rs.contract,
+			tx.Type,
+			tx.Value,
+			tx.Gas,
+			tx.To,
+			tx.From,
+			tx.Nonce,
+			tx.Token,
+			tx.Data,
+		})
+	}
+	return rlpHash([]interface{}{
+		rs.chainID,
+		rs.contract,
+		tx.Value,
+		tx.Gas,
+		tx.To,
+		tx.From,
+		tx.Nonce,
+		tx.Token,
+	})
+}
+
+//SignTx signs the transaction with the given private key
+func SignTx(tx *Transaction, signer Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	h := signer.Hash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+//Sender recovers the address that signed the transaction, V must be 27 or 28 so a signature has a single encoding
+func Sender(signer Signer, tx *Transaction) (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil || !tx.V.IsUint64() || (tx.V.Uint64() != 27 && tx.V.Uint64() != 28) {
+		return common.Address{}, ErrInvalidSig
+	}
+	v := byte(tx.V.Uint64() - 27)
+	if !crypto.ValidateSignatureValues(v, tx.R, tx.S, true) {
+		return common.Address{}, ErrInvalidSig
+	}
+	r, s := tx.R.Bytes(), tx.S.Bytes()
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig[32-len(r):32], r)
+	copy(sig[64-len(s):64], s)
+	sig[64] = v
+	h := signer.Hash(tx)
+	pub, err := crypto.Ecrecover(h[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(pub) == 0 || pub[0] != 4 {
+		return common.Address{}, ErrInvalidSig
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
+	return addr, nil
+}
+
+//VerifySender checks that the transaction was signed by its From account
+func VerifySender(signer Signer, tx *Transaction) error {
+	from, err := Sender(signer, tx)
+	if err != nil || from != tx.From {
+		return &InvalidSignature{tx.From}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+//Package simchain simulates the layer 1 chain with the go-ethereum backend to test how nodes ingest the contract logs: deposits,
+//batches, confirmations and reorgs, read through bridge.Bridge as from a real node
+//It does not test the rollup contract. The bindings carry no bytecode, so ContractAddr runs EmitterCode instead: each contract call
+//logs the event appended to its calldata. The contract logic is approximated in Go (GetStateRoot, IsStateRootValid and the
+//calls that emit events), bonds are not required and fraud proofs are not simulated
+package simchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/rogercoll/optimisticrp/bridge"
+	store "github.com/rogercoll/optimisticrp/contracts"
+	"github.com/sirupsen/logrus"
+)
+
+//EmitterCode logs the event appended to the calldata of the contract call: data || topics || number of topics || data length,
+//calls without it (the views) return a zero word
+//
+//	if calldatasize < 68 jump end
+//	L = calldataload(calldatasize - 32); n = calldataload(calldatasize - 64)
+//	T = calldatasize - 64 - 32n; calldatacopy(0, T - L, L)
+//	n == 1: log1(0, L, t0); n == 2: log2(0, L, t0, t1); n == 3: log3(0, L, t0, t1, t2) with ti = calldataload(T + 32i)
+//	end: return(msize, 32)
+var EmitterCode = common.FromHex("6044361061006c57602036033560403603358060051b604001360382810383816000375081600114610040578160021461004b578160031461005b5761006c565b8035836000a161006c565b80602001358135846000a261006c565b806040013581602001358235856000a35b602059f3")
+
+//ContractAddr runs EmitterCode in place of the rollup contract
+var ContractAddr = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+
+const gasLimit = 50000000
+
+//Chain is the bridge of a simulated chain, transactions are mined by Commit
+type Chain struct {
+	*bridge.Bridge
+	backend *backends.SimulatedBackend
+	db      ethdb.Database
+	key     *ecdsa.PrivateKey //sends the contract calls
+	abi     abi.ABI
+}
+
+//New returns a chain with only the genesis block, ConfirmedHead is confirmations blocks behind the head
+func New(confirmations uint64) (*Chain, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	contractAbi, err := abi.JSON(strings.NewReader(store.ContractsERC20ABI))
+	if err != nil {
+		return nil, err
+	}
+	db := rawdb.NewMemoryDatabase()
+	backend := backends.NewSimulatedBackendWithDatabase(db, core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+		ContractAddr:                          {Code: EmitterCode, Balance: new(big.Int)},
+	}, gasLimit)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	b, err := bridge.New(ContractAddr, backend, logger)
+	if err != nil {
+		return nil, err
+	}
+	b.SetChainID(params.AllEthashProtocolChanges.ChainID)
+	b.SetConfirmations(confirmations)
+	return &Chain{b, backend, db, key, contractAbi}, nil
+}
+
+//Backend returns the simulated layer 1 node
+func (c *Chain) Backend() *backends.SimulatedBackend {
+	return c.backend
+}
+
+//Commit mines the pending contract calls in a new block
+func (c *Chain) Commit() {
+	c.backend.Commit()
+}
+
+//Reorg replaces the blocks after ancestor with the given number of empty blocks, the contract calls they had are dropped
+//The new chain must be longer than the replaced one to become the canonical chain
+func (c *Chain) Reorg(ancestor uint64, blocks int) error {
+	ctx := context.Background()
+	head, err := c.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if ancestor+uint64(blocks) <= head.Number.Uint64() {
+		return fmt.Errorf("%s The new chain must be longer than %d blocks", optimisticrp.OPR_BANNER, head.Number.Uint64())
+	}
+	parent, err := c.backend.BlockByNumber(ctx, new(big.Int).SetUint64(ancestor))
+	if err != nil {
+		return err
+	}
+	fork, _ := core.GenerateChain(params.AllEthashProtocolChanges, parent, ethash.NewFaker(), c.db, blocks, func(int, *core.BlockGen) {})
+	if _, err := c.backend.Blockchain().InsertChain(fork); err != nil {
+		return err
+	}
+	c.backend.Rollback()
+	return nil
+}
+
+//Emit sends a call of the contract method that logs the given event, topics are its indexed arguments and data the other ones
+func (c *Chain) Emit(method string, args []interface{}, event string, topics []common.Hash, data ...interface{}) (*types.Transaction, error) {
+	ctx := context.Background()
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	logData, err := c.abi.Events[event].Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		return nil, err
+	}
+	topics = append([]common.Hash{c.abi.Events[event].ID}, topics...)
+	input = append(input, logData...)
+	for _, topic := range topics {
+		input = append(input, topic.Bytes()...)
+	}
+	input = append(input, common.BigToHash(big.NewInt(int64(len(topics)))).Bytes()...)
+	input = append(input, common.BigToHash(big.NewInt(int64(len(logData)))).Bytes()...)
+	nonce, err := c.backend.PendingNonceAt(ctx, crypto.PubkeyToAddress(c.key.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	//calldata plus the log and memory of the emitter
+	gas := params.TxGas + params.TxDataNonZeroGasFrontier*uint64(len(input)) + 100000
+	tx, err := types.SignTx(types.NewTransaction(nonce, ContractAddr, new(big.Int), gas, big.NewInt(1), input), types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID), c.key)
+	if err != nil {
+		return nil, err
+	}
+	return tx, c.backend.SendTransaction(ctx, tx)
+}
+
+//Fund sends value from the chain account to the given address, so it can send the contract calls through bridge.Bridge
+func (c *Chain) Fund(to common.Address, value *big.Int) error {
+	ctx := context.Background()
+	nonce, err := c.backend.PendingNonceAt(ctx, crypto.PubkeyToAddress(c.key.PublicKey))
+	if err != nil {
+		return err
+	}
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, value, params.TxGas, big.NewInt(1), nil), types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID), c.key)
+	if err != nil {
+		return err
+	}
+	return c.backend.SendTransaction(ctx, tx)
+}
+
+//contractState is the storage of the rollup contract at the chain head
+type contractState struct {
+	stateRoot common.Hash
+	valid     map[common.Hash]bool
+}
+
+//contractState replays the New_Batch events of the canonical chain, a batch becomes valid once the next one is accepted
+func (c *Chain) contractState() (*contractState, error) {
+	head, err := c.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	state := &contractState{valid: make(map[common.Hash]bool)}
+	stream := optimisticrp.NewEventStream(context.Background(), func(ctx context.Context, events chan<- optimisticrp.Event) error {
+		return c.Bridge.GetOnChainData(ctx, 0, head.Number.Uint64(), events)
+	})
+	for stream.Next() {
+		if input, ok := stream.Event().(optimisticrp.BatchEvent); ok && input.Batch.PrevStateRoot == state.stateRoot {
+			state.valid[state.stateRoot] = true
+			state.stateRoot = input.Batch.StateRoot
+		}
+	}
+	return state, stream.Err()
+}
+
+func (c *Chain) GetStateRoot() (common.Hash, error) {
+	state, err := c.contractState()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return state.stateRoot, nil
+}
+
+func (c *Chain) IsStateRootValid(root common.Hash) (bool, error) {
+	state, err := c.contractState()
+	if err != nil {
+		return false, err
+	}
+	return state.valid[root], nil
+}
+
+//PrepareTxOptions only sets the sender and the value, the calls are sent by the chain account
+func (c *Chain) PrepareTxOptions(value, gasLimit, gasPrice *big.Int, privKey *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
+	auth := bind.NewKeyedTransactor(privKey)
+	auth.Value = value
+	return auth, nil
+}
+
+//NewBatch logs the batch like the contract, that reverts the batches that do not extend its state root
+func (c *Chain) NewBatch(batch optimisticrp.SolidityBatch, txOpts *bind.TransactOpts) (*types.Transaction, error) {
+	stateRoot, err := c.GetStateRoot()
+	if err != nil {
+		return nil, err
+	}
+	if batch.PrevStateRoot != stateRoot {
+		return nil, fmt.Errorf("%s Batch does not extend the state root %v", optimisticrp.OPR_BANNER, stateRoot.Hex())
+	}
+	goBatch, err := batch.ToGolangFormat()
+	if err != nil {
+		return nil, err
+	}
+	result, err := optimisticrp.EncodeBatch(&goBatch, optimisticrp.LegacyBatchVersion)
+	if err != nil {
+		return nil, err
+	}
+	return c.Emit("newBatch", []interface{}{result}, "New_Batch", []common.Hash{txOpts.From.Hash()}, batch.PrevStateRoot, batch.StateRoot)
+}
+
+func (c *Chain) Deposit(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+	stateRoot, err := c.GetStateRoot()
+	if err != nil {
+		return nil, err
+	}
+	return c.Emit("deposit", nil, "New_Deposit", nil, txOpts.From, stateRoot, txOpts.Value)
+}
+
+//Withdraw takes the balance of the proven account, the proof is not verified
+func (c *Chain) Withdraw(txOpts *bind.TransactOpts, address, value, proof, stateRoot []byte) (*types.Transaction, error) {
+	var acc optimisticrp.SolidityAccount
+	if err := rlp.DecodeBytes(value, &acc); err != nil {
+		return nil, err
+	}
+	goFormat, err := acc.ToGolangFormat()
+	if err != nil {
+		return nil, err
+	}
+	root := common.BytesToHash(stateRoot)
+	return c.Emit("withdraw", []interface{}{address, value, proof, root}, "New_withdraw", nil, txOpts.From, root, goFormat.Balance)
+}
+
+//Bond does nothing, any account can submit batches
+func (c *Chain) Bond(*bind.TransactOpts) (*types.Transaction, error) {
+	return nil, nil
+}
+
+//FraudProof fails, the contract fraud proof can not be simulated without its bytecode
+func (c *Chain) FraudProof(*bind.TransactOpts, []byte, []byte, []byte, []byte, optimisticrp.SolidityBatch) (*types.Transaction, error) {
+	return nil, fmt.Errorf("%s Fraud proofs are not simulated", optimisticrp.OPR_BANNER)
+}
@@ -0,0 +1,209 @@
+package optimisticrp
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//Height of the sparse Merkle tree, accounts are placed at the keccak256 hash of their address
+const smtDepth = 256
+
+//Hashes of the empty subtrees by height, defaultHashes[0] is an empty leaf
+var defaultHashes = func() [smtDepth + 1]common.Hash {
+	var defaults [smtDepth + 1]common.Hash
+	for h := 1; h <= smtDepth; h++ {
+		defaults[h] = crypto.Keccak256Hash(defaults[h-1].Bytes(), defaults[h-1].Bytes())
+	}
+	return defaults
+}()
+
+//SparseMerkleTree is an in-memory Optimistic implementation whose proofs have a fixed size of 256 siblings
+//Nodes are immutable and addressed by their hash, so snapshots only need to remember the root
+type SparseMerkleTree struct {
+	root      common.Hash
+	nodes     map[common.Hash][2]common.Hash //non empty internal nodes
+	values    map[common.Hash][]byte         //leaf hash -> RLP encoded account
+	snapshots []common.Hash
+}
+
+func NewSparseMerkleTree() *SparseMerkleTree {
+	return &SparseMerkleTree{
+		root:   defaultHashes[smtDepth],
+		nodes:  make(map[common.Hash][2]common.Hash),
+		values: make(map[common.Hash][]byte),
+	}
+}
+
+func (smt *SparseMerkleTree) StateRoot() common.Hash {
+	return smt.root
+}
+
+func (smt *SparseMerkleTree) GetAccount(address common.Address) (Account, error) {
+	leaf, _ := smt.path(address)
+	if leaf == defaultHashes[0] {
+		return Account{}, &AccountNotFound{address}
+	}
+	var acc SolidityAccount
+	if err := rlp.DecodeBytes(smt.values[leaf], &acc); err != nil {
+		return Account{}, err
+	}
+	return acc.ToGolangFormat()
+}
+
+func (smt *SparseMerkleTree) UpdateAccount(address common.Address, acc Account) common.Hash {
+	val, err := rlp.EncodeToBytes(acc.SolidityFormat())
+	if err != nil {
+		panic(err)
+	}
+	leaf := crypto.Keccak256Hash(val)
+	smt.values[leaf] = val
+	_, siblings := smt.path(address)
+	key := smtKey(address)
+	for d := smtDepth - 1; d >= 0; d-- {
+		var children [2]common.Hash
+		if bit(key, d) == 0 {
+			children = [2]common.Hash{leaf, siblings[d]}
+		} else {
+			children = [2]common.Hash{siblings[d], leaf}
+		}
+		leaf = crypto.Keccak256Hash(children[0].Bytes(), children[1].Bytes())
+		if leaf != defaultHashes[smtDepth-d] {
+			smt.nodes[leaf] = children
+		}
+	}
+	smt.root = leaf
+	return smt.root
+}
+
+//NewProve returns key, value, compressed proof and root. The proof is a 32 bytes bitmap of the non empty siblings followed by them, from the root to the leaf
+func (smt *SparseMerkleTree) NewProve(address common.Address) ([][]byte, error) {
+	leaf, siblings := smt.path(address)
+	if leaf == defaultHashes[0] {
+		return nil, &AccountNotFound{address}
+	}
+	return [][]byte{address.Bytes(), smt.values[leaf], CompressSparseProof(siblings), smt.root.Bytes()}, nil
+}
+
+//NewExclusionProof proves that the address has no account, it has the same layout than NewProve with an empty value
+func (smt *SparseMerkleTree) NewExclusionProof(address common.Address) ([][]byte, error) {
+	leaf, siblings := smt.path(address)
+	if leaf != defaultHashes[0] {
+		return nil, &AccountFound{address}
+	}
+	return [][]byte{address.Bytes(), nil, CompressSparseProof(siblings), smt.root.Bytes()}, nil
+}
+
+func (smt *SparseMerkleTree) Snapshot() int {
+	smt.snapshots = append(smt.snapshots, smt.root)
+	return len(smt.snapshots) - 1
+}
+
+func (smt *SparseMerkleTree) RevertToSnapshot(revid int) {
+	if revid < 0 || revid >= len(smt.snapshots) {
+		panic(fmt.Errorf("revision id %v cannot be reverted", revid))
+	}
+	smt.root = smt.snapshots[revid]
+	smt.snapshots = smt.snapshots[:revid]
+}
+
+func (smt *SparseMerkleTree) DiscardSnapshot(revid int) {
+	if revid >= 0 && revid < len(smt.snapshots) {
+		smt.snapshots = smt.snapshots[:revid]
+	}
+}
+
+//Reset empties the tree and invalidates all the snapshots
+func (smt *SparseMerkleTree) Reset() {
+	*smt = *NewSparseMerkleTree()
+}
+
+//path returns the leaf hash of the address and its siblings from the root to the leaf
+func (smt *SparseMerkleTree) path(address common.Address) (common.Hash, []common.Hash) {
+	key := smtKey(address)
+	siblings := make([]common.Hash, smtDepth)
+	node := smt.root
+	for d := 0; d < smtDepth; d++ {
+		if node == defaultHashes[smtDepth-d] {
+			for ; d < smtDepth; d++ {
+				siblings[d] = defaultHashes[smtDepth-d-1]
+			}
+			return defaultHashes[0], siblings
+		}
+		children := smt.nodes[node]
+		node, siblings[d] = children[bit(key, d)], children[1-bit(key, d)]
+	}
+	return node, siblings
+}
+
+//CompressSparseProof drops the empty siblings of a proof, they are marked in the leading bitmap
+func CompressSparseProof(siblings []common.Hash) []byte {
+	bitmap := make([]byte, smtDepth/8)
+	proof := []byte{}
+	for d, sibling := range siblings {
+		if sibling != defaultHashes[smtDepth-d-1] {
+			bitmap[d/8] |= 1 << (7 - uint(d%8))
+			proof = append(proof, sibling.Bytes()...)
+		}
+	}
+	return append(bitmap, proof...)
+}
+
+//DecompressSparseProof returns the 256 siblings of a compressed proof
+func DecompressSparseProof(proof []byte) ([]common.Hash, error) {
+	if len(proof) < smtDepth/8 || (len(proof)-smtDepth/8)%common.HashLength != 0 {
+		return nil, fmt.Errorf("%s Invalid sparse proof length %v", OPR_BANNER, len(proof))
+	}
+	bitmap, hashes := proof[:smtDepth/8], proof[smtDepth/8:]
+	siblings := make([]common.Hash, smtDepth)
+	for d := range siblings {
+		if bit(bitmap, d) == 0 {
+			siblings[d] = defaultHashes[smtDepth-d-1]
+			continue
+		}
+		if len(hashes) == 0 {
+			return nil, fmt.Errorf("%s Sparse proof has less siblings than its bitmap", OPR_BANNER)
+		}
+		siblings[d], hashes = common.BytesToHash(hashes[:common.HashLength]), hashes[common.HashLength:]
+	}
+	if len(hashes) != 0 {
+		return nil, fmt.Errorf("%s Sparse proof has more siblings than its bitmap", OPR_BANNER)
+	}
+	return siblings, nil
+}
+
+//VerifySparseProof checks offline a compressed proof of the sparse Merkle tree, an empty value verifies an exclusion proof
+func VerifySparseProof(root common.Hash, address common.Address, value, proof []byte) error {
+	siblings, err := DecompressSparseProof(proof)
+	if err != nil {
+		return err
+	}
+	node := defaultHashes[0]
+	if len(value) != 0 {
+		node = crypto.Keccak256Hash(value)
+	}
+	key := smtKey(address)
+	for d := smtDepth - 1; d >= 0; d-- {
+		if bit(key, d) == 0 {
+			node = crypto.Keccak256Hash(node.Bytes(), siblings[d].Bytes())
+		} else {
+			node = crypto.Keccak256Hash(siblings[d].Bytes(), node.Bytes())
+		}
+	}
+	if !bytes.Equal(node.Bytes(), root.Bytes()) {
+		return &InvalidProof{address, root}
+	}
+	return nil
+}
+
+func smtKey(address common.Address) []byte {
+	return crypto.Keccak256(address.Bytes())
+}
+
+//bit returns the i-th bit of key, most significant bit first
+func bit(key []byte, i int) int {
+	return int(key[i/8]>>(7-uint(i%8))) & 1
+}
@@ -0,0 +1,148 @@
+package optimisticrp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSparseMerkleTree(t *testing.T) {
+	smt := NewSparseMerkleTree()
+	if _, err := smt.GetAccount(address1); err == nil {
+		t.Errorf("Empty tree must not contain %v", address1)
+	}
+	smt.UpdateAccount(address1, acc1)
+	smt.UpdateAccount(address2, acc2)
+	root := smt.UpdateAccount(address3, acc3)
+	reversed := NewSparseMerkleTree()
+	reversed.UpdateAccount(address3, acc3)
+	reversed.UpdateAccount(address2, acc2)
+	if reversed.UpdateAccount(address1, acc1) != root {
+		t.Errorf("StateRoot must not depend on the updates order")
+	}
+	acc, err := smt.GetAccount(address2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Nonce != acc2.Nonce || acc.Balance.Cmp(acc2.Balance) != 0 {
+		t.Errorf("Account = %v; want %v", acc, acc2)
+	}
+	toSend, err := smt.NewProve(address2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySparseProof(root, address2, toSend[1], toSend[2]); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySparseProof(root, address1, toSend[1], toSend[2]); err == nil {
+		t.Errorf("Proof of %v must not be valid for %v", address2, address1)
+	}
+	exclusion, err := smt.NewExclusionProof(address4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySparseProof(root, address4, nil, exclusion[2]); err != nil {
+		t.Error(err)
+	}
+	if len(toSend[2]) >= smtDepth*common.HashLength {
+		t.Errorf("Compressed proof size = %v; must be smaller than the full proof", len(toSend[2]))
+	}
+}
+
+func TestSparseRevertToSnapshot(t *testing.T) {
+	smt := NewSparseMerkleTree()
+	smt.UpdateAccount(address1, acc1)
+	root := smt.StateRoot()
+	snapshot := smt.Snapshot()
+	smt.UpdateAccount(address1, acc2)
+	smt.UpdateAccount(address2, acc2)
+	smt.RevertToSnapshot(snapshot)
+	if smt.StateRoot() != root {
+		t.Errorf("StateRoot = %v; want %v", smt.StateRoot(), root)
+	}
+	if _, err := smt.GetAccount(address2); err == nil {
+		t.Errorf("Account %v must be reverted", address2)
+	}
+	//applied batches release their snapshot
+	for i := 0; i < 3; i++ {
+		smt.DiscardSnapshot(smt.Snapshot())
+	}
+	if len(smt.snapshots) != 0 {
+		t.Errorf("Snapshots = %d; want 0 after discarding them", len(smt.snapshots))
+	}
+	smt.Reset()
+	if smt.StateRoot() != defaultHashes[smtDepth] {
+		t.Errorf("Reset tree must be empty")
+	}
+}
+
+func TestOpenSparseState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "optimisticrp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.OpenState(SparseBackend); err == nil {
+		t.Errorf("The sparse backend must not be opened on a persistent database")
+	}
+	if _, err := NewMemoryDatabase().OpenState(SparseBackend); err != nil {
+		t.Error(err)
+	}
+}
+
+func benchmarkBackends(b *testing.B, run func(b *testing.B, state Optimistic, addresses []common.Address)) {
+	for _, accounts := range []int{100, 1000, 10000} {
+		addresses := make([]common.Address, accounts)
+		for i := range addresses {
+			addresses[i] = common.BytesToAddress(crypto.Keccak256(big.NewInt(int64(i)).Bytes()))
+		}
+		for _, backend := range []Backend{TrieBackend, SparseBackend} {
+			b.Run(fmt.Sprintf("%s/accounts=%d", backend, accounts), func(b *testing.B) {
+				state, err := NewMemoryDatabase().OpenState(backend)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, addr := range addresses {
+					state.UpdateAccount(addr, acc1)
+				}
+				b.ResetTimer()
+				run(b, state, addresses)
+			})
+		}
+	}
+}
+
+//BenchmarkBackendProve compares proof generation and proof sizes
+func BenchmarkBackendProve(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, state Optimistic, addresses []common.Address) {
+		size := 0
+		for i := 0; i < b.N; i++ {
+			toSend, err := state.NewProve(addresses[i%len(addresses)])
+			if err != nil {
+				b.Fatal(err)
+			}
+			size += len(toSend[2])
+		}
+		b.ReportMetric(float64(size)/float64(b.N), "proof-bytes")
+	})
+}
+
+//BenchmarkBackendUpdate compares the hashing cost of account updates
+func BenchmarkBackendUpdate(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, state Optimistic, addresses []common.Address) {
+		for i := 0; i < b.N; i++ {
+			state.UpdateAccount(addresses[i%len(addresses)], acc2)
+			state.StateRoot()
+		}
+	})
+}
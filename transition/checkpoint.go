@@ -0,0 +1,250 @@
+package transition
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+	"github.com/sirupsen/logrus"
+)
+
+//LoadCheckpoint opens the state at its newest valid checkpoint and returns it with the number of newer checkpoints discarded, it is nil if the state
+//does not support checkpoints or none is valid and the state must be computed from scratch
+//Checkpoints above head or of blocks no longer in the chain are discarded, so the state is rolled back to the last block shared with the current chain.
+//So are the ones whose last batch was reverted by a fraud proof
+func LoadCheckpoint(state optimisticrp.Optimistic, contract optimisticrp.OptimisticSContract, head uint64) (*optimisticrp.Checkpoint, int, error) {
+	checkpointer, ok := state.(optimisticrp.Checkpointer)
+	if !ok {
+		return nil, 0, nil
+	}
+	checkpoints, err := checkpointer.Checkpoints()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		err := checkCheckpoint(contract, checkpoints[i], head)
+		if _, ok := err.(*optimisticrp.InvalidCheckpoint); ok {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+		//the state of the checkpoint may have not been committed
+		if err := checkpointer.ResetTo(checkpoints[i].StateRoot); err != nil {
+			continue
+		}
+		return checkpoints[i], len(checkpoints) - 1 - i, nil
+	}
+	return nil, len(checkpoints), nil
+}
+
+func checkCheckpoint(contract optimisticrp.OptimisticSContract, checkpoint *optimisticrp.Checkpoint, head uint64) error {
+	if checkpoint.Block > head {
+		return &optimisticrp.InvalidCheckpoint{Block: checkpoint.Block, Reason: "block is not confirmed"}
+	}
+	hash, err := contract.BlockHash(checkpoint.Block)
+	if err != nil {
+		return err
+	}
+	if hash != checkpoint.BlockHash {
+		return &optimisticrp.InvalidCheckpoint{Block: checkpoint.Block, Reason: "block is not in the chain"}
+	}
+	if checkpoint.Batch == 0 {
+		return nil
+	}
+	valid, err := contract.IsStateRootValid(checkpoint.LastRoot)
+	if err != nil {
+		return err
+	}
+	onChainStateRoot, err := contract.GetStateRoot()
+	if err != nil {
+		return err
+	}
+	if !valid && checkpoint.LastRoot != onChainStateRoot {
+		return &optimisticrp.InvalidCheckpoint{Block: checkpoint.Block, Reason: "last batch was reverted"}
+	}
+	return nil
+}
+
+//BatchReplayer applies a batch read from the chain on top of the deposits and withdraws done before it, Syncer.Replay is the default one
+//Nodes replace it to check the batches, the sync stops with the error it returns
+type BatchReplayer func(input optimisticrp.BatchEvent, batch optimisticrp.Batch, status Status, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw) (common.Hash, []Receipt, error)
+
+//Syncer applies the on-chain data of the confirmed blocks to the accounts state of a node, resuming from its newest valid checkpoint
+type Syncer struct {
+	state    optimisticrp.Optimistic
+	contract optimisticrp.OptimisticSContract
+	signer   optimisticrp.Signer
+	replay   BatchReplayer
+	log      *logrus.Entry
+}
+
+func NewSyncer(state optimisticrp.Optimistic, contract optimisticrp.OptimisticSContract, logger *logrus.Entry) *Syncer {
+	s := &Syncer{state: state, contract: contract, log: logger}
+	s.replay = s.Replay
+	return s
+}
+
+//SetReplayer replaces the function that applies the batches read
+func (s *Syncer) SetReplayer(replay BatchReplayer) {
+	s.replay = replay
+}
+
+//Replay applies the batch with the rollup rules, see Replay
+func (s *Syncer) Replay(input optimisticrp.BatchEvent, batch optimisticrp.Batch, status Status, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw) (common.Hash, []Receipt, error) {
+	signer, err := s.Signer()
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	return Replay(s.state, signer, deposits, withdraws, batch, status)
+}
+
+//Signer of the rollup contract transactions, the chain id is only fetched once
+func (s *Syncer) Signer() (optimisticrp.Signer, error) {
+	if s.signer == nil {
+		signer, err := optimisticrp.ContractSigner(s.contract)
+		if err != nil {
+			return nil, err
+		}
+		s.signer = signer
+	}
+	return s.signer, nil
+}
+
+//Sync reads the on-chain data of the confirmed blocks after the last valid checkpoint and applies it to the accounts state, it is computed from scratch if there is none
+//Returns the checkpoints of the blocks read, the last one is the confirmed head. They are only persisted by WriteCheckpoints, once the node checked the state
+//The rollup rules are applied by this package, so any Optimistic implementation that can be reset is supported
+func (s *Syncer) Sync() ([]*optimisticrp.Checkpoint, error) {
+	head, headHash, err := s.contract.ConfirmedHead()
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, from, err := s.resumeCheckpoint(head)
+	if err != nil {
+		return nil, err
+	}
+	//returning before the end cancels the stream
+	stream := optimisticrp.StreamOnChainData(context.Background(), s.contract, from, head)
+	defer stream.Close()
+	stateRoot := checkpoint.LastRoot
+	batchNumber := checkpoint.Batch
+	pendingDeposits := checkpoint.Deposits
+	pendingWithdraws := checkpoint.Withdraws
+	//the resumed checkpoint is the parent of the first block read, it is written again to drop the checkpoints of replaced blocks
+	last := checkpoint
+	var checkpoints []*optimisticrp.Checkpoint
+	if checkpoint.BlockHash != (common.Hash{}) {
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	for stream.Next() {
+		switch input := stream.Event().(type) {
+		case optimisticrp.BatchEvent:
+			batch, err := input.Batch.ToGolangFormat()
+			if err != nil {
+				return nil, err
+			}
+			batchNumber++
+			//if there is a new batch we MUST update the stateRoot with the previous deposits (rule 1.)
+			status, err := BatchStatus(s.contract, batch)
+			if err != nil {
+				return nil, err
+			}
+			s.log.WithFields(logrus.Fields{"Batch": batchNumber, "Status": status}).Info("New onChain Batch received")
+			root, receipts, err := s.replay(input, batch, status, pendingDeposits, pendingWithdraws)
+			pendingDeposits = nil
+			pendingWithdraws = nil
+			if err != nil {
+				return nil, err
+			}
+			if status != Reverted {
+				stateRoot = root
+				s.log.WithFields(logrus.Fields{"Transactions": len(receipts)}).Info("Accounts state updated with the batch transactions")
+			} else {
+				s.log.Debug("Skipping invalid onChain batch")
+			}
+			if err := s.Commit(batchNumber); err != nil {
+				return nil, err
+			}
+		case optimisticrp.L1Block:
+			if input.Number == last.Block+1 && last.BlockHash != (common.Hash{}) && input.ParentHash != last.BlockHash {
+				return nil, &optimisticrp.Reorg{Block: input.Number}
+			}
+			last = &optimisticrp.Checkpoint{
+				Block:     input.Number,
+				BlockHash: input.Hash,
+				StateRoot: s.state.StateRoot(),
+				Batch:     batchNumber,
+				LastRoot:  stateRoot,
+				Deposits:  pendingDeposits,
+				Withdraws: pendingWithdraws,
+			}
+			checkpoints = append(checkpoints, last)
+		case optimisticrp.DepositEvent:
+			s.log.WithFields(logrus.Fields{"Account": input.Deposit.From, "Token": input.Deposit.Token, "Value": input.Deposit.Value, "Block": input.BlockNumber}).Info("New onChain deposit")
+			pendingDeposits = append(pendingDeposits, input.Deposit)
+		case optimisticrp.WithdrawEvent:
+			s.log.WithFields(logrus.Fields{"Account": input.Withdraw.From, "Token": input.Withdraw.Token, "Value": input.Withdraw.Value, "Block": input.BlockNumber}).Info("New onChain withdraw")
+			pendingWithdraws = append(pendingWithdraws, input.Withdraw)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	//the chain changed under the last block while it was read
+	if last.Block != head || last.BlockHash != headHash {
+		return nil, &optimisticrp.Reorg{Block: head}
+	}
+	return checkpoints, nil
+}
+
+//Opens the accounts state at the persisted checkpoint and returns the first block to read, if there is no valid checkpoint the state is reset to be computed from scratch
+func (s *Syncer) resumeCheckpoint(head uint64) (*optimisticrp.Checkpoint, uint64, error) {
+	checkpoint, discarded, err := LoadCheckpoint(s.state, s.contract, head)
+	if err != nil {
+		return nil, 0, err
+	}
+	if discarded > 0 {
+		//reorged or reverted blocks
+		s.log.WithFields(logrus.Fields{"Discarded": discarded}).Warn("Checkpoints invalidated, rolling back the accounts state")
+	}
+	if checkpoint != nil {
+		s.log.WithFields(logrus.Fields{"Block": checkpoint.Block, "Batch": checkpoint.Batch}).Info("Resuming from checkpoint")
+		return checkpoint, checkpoint.Block + 1, nil
+	}
+	resetter, ok := s.state.(optimisticrp.Resetter)
+	if ok != true {
+		return nil, 0, fmt.Errorf("The accounts state must implement optimisticrp.Resetter to be computed from scratch")
+	}
+	//the accounts trie is computed from scratch, any previous state is discarded
+	resetter.Reset()
+	return &optimisticrp.Checkpoint{}, 0, nil
+}
+
+//Commit persists the accounts state after a processed batch if the Optimistic implementation supports it
+func (s *Syncer) Commit(batch uint64) error {
+	committer, ok := s.state.(optimisticrp.Committer)
+	if !ok {
+		return nil
+	}
+	root, err := committer.CommitBatch(batch)
+	if err != nil {
+		return err
+	}
+	s.log.WithFields(logrus.Fields{"Batch": batch, "StateRoot": root}).Debug("Committed accounts state")
+	return nil
+}
+
+//WriteCheckpoints persists the processed layer 1 blocks if the Optimistic implementation supports it
+func (s *Syncer) WriteCheckpoints(checkpoints []*optimisticrp.Checkpoint) error {
+	checkpointer, ok := s.state.(optimisticrp.Checkpointer)
+	if !ok {
+		return nil
+	}
+	for _, checkpoint := range checkpoints {
+		if err := checkpointer.WriteCheckpoint(checkpoint); err != nil {
+			return err
+		}
+		s.log.WithFields(logrus.Fields{"Block": checkpoint.Block, "StateRoot": checkpoint.StateRoot}).Debug("Checkpoint written")
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+//Package transition implements the rollup consensus rules, every node (aggregator, challenger or any third-party verifier)
+//must use it to apply on-chain data to the accounts state so they never diverge
+package transition
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+)
+
+//Status of a batch found on chain
+type Status int
+
+const (
+	//Valid batches have been accepted, a new batch was submitted on top of them
+	Valid Status = iota
+	//Pending is the last submitted batch, its fraud proof period may not have expired yet
+	Pending
+	//Reverted batches were proven fraudulent, only the deposits and withdraws before them are applied
+	Reverted
+)
+
+func (s Status) String() string {
+	switch s {
+	case Valid:
+		return "valid"
+	case Pending:
+		return "pending"
+	case Reverted:
+		return "reverted"
+	default:
+		return "unknown"
+	}
+}
+
+//Receipt is the outcome of a transaction applied to the accounts state
+type Receipt struct {
+	Index     int
+	From      common.Address
+	To        common.Address //credited account, the withdrawal receipt account of withdrawals and empty for multi-sends (see Payments)
+	Token     common.Address
+	Value     *big.Int
+	Fee       *big.Int    //paid to the batch submitter
+	StateRoot common.Hash //accounts state root right after the transaction
+	Err       error       //invalid transaction skipped by Replay, the state is left as before it
+}
+
+//BatchStatus asks the on-chain contract for the status of the given batch
+func BatchStatus(contract optimisticrp.OptimisticSContract, batch optimisticrp.Batch) (Status, error) {
+	isValid, err := contract.IsStateRootValid(batch.StateRoot)
+	if err != nil {
+		return Reverted, err
+	}
+	if isValid {
+		return Valid, nil
+	}
+	onChainStateRoot, err := contract.GetStateRoot()
+	if err != nil {
+		return Reverted, err
+	}
+	if batch.StateRoot == onChainStateRoot {
+		return Pending, nil
+	}
+	return Reverted, nil
+}
+
+//Replay applies the rollup rules in order: the deposits and withdraws done since the last batch (rule 1.) and then the batch
+//transactions according to its status, whose fees are credited to the batch submitter. Reverted batches only apply the deposits and withdraws
+//The contract only reverts the batches whose fraud is proven, so an invalid transaction is skipped unless it is provable (see Provable)
+//and the batch is Pending: its receipt has Err set and the state is left as before it. Every node follows this rule, a Valid batch is never rejected.
+//A provable invalid transaction of a Pending batch reverts its transactions and its error is returned, to be proven on-chain
+func Replay(state optimisticrp.Optimistic, signer optimisticrp.Signer, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw, batch optimisticrp.Batch, status Status) (common.Hash, []Receipt, error) {
+	if err := ApplyOnChainData(state, deposits, withdraws); err != nil || status == Reverted {
+		return state.StateRoot(), nil, err
+	}
+	snapshot := state.Snapshot()
+	receipts := make([]Receipt, 0, len(batch.Transactions))
+	for i, tx := range batch.Transactions {
+		txSnapshot := state.Snapshot()
+		stateRoot, err := ProcessTx(state, signer, batch.Submitter, tx)
+		if err != nil && status == Pending && Provable(err) {
+			state.RevertToSnapshot(snapshot)
+			return state.StateRoot(), nil, err
+		}
+		if err != nil {
+			state.RevertToSnapshot(txSnapshot)
+			receipts = append(receipts, Receipt{Index: i, From: tx.From, To: tx.Recipient(), Token: tx.Token, Value: tx.Value, Fee: new(big.Int), StateRoot: state.StateRoot(), Err: err})
+			continue
+		}
+		state.DiscardSnapshot(txSnapshot)
+		receipts = append(receipts, Receipt{Index: i, From: tx.From, To: tx.Recipient(), Token: tx.Token, Value: tx.Value, Fee: tx.Fee(), StateRoot: stateRoot})
+	}
+	state.DiscardSnapshot(snapshot)
+	return state.StateRoot(), receipts, nil
+}
+
+//Provable reports whether an invalid transaction can be proven with the contract fraud proof, which only replays the ether balances.
+//Signatures, nonces, transaction types and token balances are not checked on-chain
+func Provable(err error) bool {
+	fraud, ok := err.(*optimisticrp.InvalidBalance)
+	return ok && fraud.Token == optimisticrp.Ether
+}
+
+//ApplyOnChainData applies the deposits and then the withdraws done in the contract
+func ApplyOnChainData(state optimisticrp.Optimistic, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw) error {
+	for _, deposit := range deposits {
+		if err := AddFunds(state, deposit.From, deposit.Token, deposit.Value); err != nil {
+			return err
+		}
+	}
+	for _, withdraw := range withdraws {
+		if err := RemoveFunds(state, withdraw.From, withdraw.Token, withdraw.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//AddFunds credits value of the given asset (Ether or an ERC20 token address) to the account, it is created if it does not exist
+func AddFunds(state optimisticrp.Optimistic, account, asset common.Address, value *big.Int) error {
+	if err := checkValue(account, value); err != nil {
+		return err
+	}
+	acc, err := state.GetAccount(account)
+	switch err.(type) {
+	case nil:
+	case *optimisticrp.AccountNotFound:
+		acc = optimisticrp.Account{Balance: new(big.Int), Nonce: 0}
+	default:
+		return err
+	}
+	acc.SetBalanceOf(asset, new(big.Int).Add(acc.BalanceOf(asset), value))
+	state.UpdateAccount(account, acc)
+	return nil
+}
+
+//RemoveFunds debits value of the given asset from the account, which must exist and hold it
+func RemoveFunds(state optimisticrp.Optimistic, account, asset common.Address, value *big.Int) error {
+	if err := checkValue(account, value); err != nil {
+		return err
+	}
+	acc, err := state.GetAccount(account)
+	if err != nil {
+		return err
+	}
+	balance := acc.BalanceOf(asset)
+	if balance.Cmp(value) == -1 {
+		return &optimisticrp.InvalidBalance{Addr: account, Token: asset, Total: balance}
+	}
+	acc.SetBalanceOf(asset, new(big.Int).Sub(balance, value))
+	state.UpdateAccount(account, acc)
+	return nil
+}
+
+//CheckAmounts rejects the transactions with a missing or negative value or fee
+func CheckAmounts(transaction optimisticrp.Transaction) error {
+	if err := checkValue(transaction.From, transaction.Value); err != nil {
+		return err
+	}
+	return checkValue(transaction.From, transaction.Fee())
+}
+
+//checkValue rejects the missing and negative amounts before they touch the state
+func checkValue(account common.Address, value *big.Int) error {
+	if value == nil || value.Sign() < 0 {
+		return &optimisticrp.InvalidValue{Addr: account, Value: value}
+	}
+	return nil
+}
+
+//TxProcessor applies a transaction kind once its signature was verified: it checks the sender nonce and balances,
+//updates the accounts and pays the fee to the batch submitter
+type TxProcessor func(state optimisticrp.Optimistic, submitter common.Address, transaction optimisticrp.Transaction) error
+
+var txProcessors = map[optimisticrp.TxType]TxProcessor{
+	optimisticrp.TransferTxType:  processTransfer,
+	optimisticrp.MultiSendTxType: processMultiSend,
+}
+
+//RegisterTxType makes ProcessTx apply the transactions of the given type, every node must register the same kinds
+//It must be called from an init function and panics if the type is already registered
+func RegisterTxType(txType optimisticrp.TxType, processor TxProcessor) {
+	if txType > optimisticrp.MaxTxType {
+		panic(fmt.Errorf("transaction type %d does not fit the envelope", txType))
+	}
+	if _, ok := txProcessors[txType]; ok {
+		panic(fmt.Errorf("transaction type %d already registered", txType))
+	}
+	txProcessors[txType] = processor
+}
+
+//KnownTxType reports whether ProcessTx can apply transactions of the given type
+func KnownTxType(txType optimisticrp.TxType) bool {
+	_, ok := txProcessors[txType]
+	return ok
+}
+
+//ProcessTx verifies the transaction signature and applies it with the processor of its type, unknown types are invalid transactions
+func ProcessTx(state optimisticrp.Optimistic, signer optimisticrp.Signer, submitter common.Address, transaction optimisticrp.Transaction) (common.Hash, error) {
+	process, ok := txProcessors[transaction.Type]
+	if !ok {
+		return common.Hash{}, &optimisticrp.UnknownTxType{Type: transaction.Type}
+	}
+	//negative amounts can not even be hashed to verify the signature
+	if err := CheckAmounts(transaction); err != nil {
+		return common.Hash{}, err
+	}
+	if err := optimisticrp.VerifySender(signer, &transaction); err != nil {
+		return common.Hash{}, err
+	}
+	if err := process(state, submitter, transaction); err != nil {
+		return common.Hash{}, err
+	}
+	return state.StateRoot(), nil
+}
+
+//processTransfer moves the transaction value from the sender to the receiver, the receiver account is created if it does not exist
+//The transaction must carry the sender account nonce. The sender pays the transaction fee to the batch submitter
+//Withdrawal transactions (To == WithdrawalAddress) burn the value into the sender withdrawal receipt account, claimable on layer 1
+func processTransfer(state optimisticrp.Optimistic, submitter common.Address, transaction optimisticrp.Transaction) error {
+	if err := debitSender(state, transaction); err != nil {
+		return err
+	}
+	//receiver and submitter accounts are read again as they may be the sender one, withdrawals leave the value in their receipt account
+	if err := AddFunds(state, transaction.Recipient(), transaction.Token, transaction.Value); err != nil {
+		return err
+	}
+	return payFee(state, submitter, transaction)
+}
+
+//processMultiSend pays every recipient of the transaction from the sender, the sender balance must cover all of them or none is paid
+func processMultiSend(state optimisticrp.Optimistic, submitter common.Address, transaction optimisticrp.Transaction) error {
+	payments, err := transaction.Payments()
+	if err != nil {
+		return err
+	}
+	//Value is the payments total, so debiting it checks the whole multi-send before any recipient is credited
+	if err := debitSender(state, transaction); err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		if err := AddFunds(state, payment.To, transaction.Token, payment.Value); err != nil {
+			return err
+		}
+	}
+	return payFee(state, submitter, transaction)
+}
+
+//debitSender checks the sender nonce and balances, then charges the transaction cost and the token value and increments the nonce
+func debitSender(state optimisticrp.Optimistic, transaction optimisticrp.Transaction) error {
+	if err := CheckAmounts(transaction); err != nil {
+		return err
+	}
+	fromAcc, err := state.GetAccount(transaction.From)
+	if err != nil {
+		return err
+	}
+	if transaction.Nonce != fromAcc.Nonce {
+		return &optimisticrp.InvalidNonce{Addr: transaction.From, Expected: fromAcc.Nonce, Got: transaction.Nonce}
+	}
+	//the ether balance must cover the fee (plus the value of ether transfers) and the token balance the value of token transfers
+	cost := transaction.Cost()
+	if fromAcc.Balance.Cmp(cost) == -1 {
+		return &optimisticrp.InvalidBalance{Addr: transaction.From, Token: optimisticrp.Ether, Total: fromAcc.Balance}
+	}
+	if transaction.Token != optimisticrp.Ether {
+		tokenBalance := fromAcc.BalanceOf(transaction.Token)
+		if tokenBalance.Cmp(transaction.Value) == -1 {
+			return &optimisticrp.InvalidBalance{Addr: transaction.From, Token: transaction.Token, Total: tokenBalance}
+		}
+		fromAcc.SetBalanceOf(transaction.Token, tokenBalance.Sub(tokenBalance, transaction.Value))
+	}
+	fromAcc.Balance.Sub(fromAcc.Balance, cost)
+	fromAcc.Nonce++
+	state.UpdateAccount(transaction.From, fromAcc)
+	return nil
+}
+
+func payFee(state optimisticrp.Optimistic, submitter common.Address, transaction optimisticrp.Transaction) error {
+	if fee := transaction.Fee(); fee.Sign() > 0 {
+		return AddFunds(state, submitter, optimisticrp.Ether, fee)
+	}
+	return nil
+}
+
+//ErrMalformedRoots is found when the intermediate roots of a batch are not sorted or point outside of its transactions
+var ErrMalformedRoots = errors.New("intermediate roots are not sorted or out of the batch transactions")
+
+//Divergence is the first step of a batch whose claimed intermediate root can not be computed, it is scoped to the
+//transactions From..To (both included) applied on top of PreStateRoot
+type Divergence struct {
+	Step         int //position in batch.IntermediateRoots
+	From, To     int
+	PreStateRoot common.Hash
+	Claimed      common.Hash
+	Computed     common.Hash //empty if a transaction of the step is invalid
+	Err          error       //invalid transaction of the step, if any
+	//Proof of the accounts touched by the step at PreStateRoot, nil if the state does not support multiproofs
+	Proof *optimisticrp.MultiProof
+}
+
+func (d *Divergence) Error() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s Batch step %d (transactions %d-%d) is invalid: %v", optimisticrp.OPR_BANNER, d.Step, d.From, d.To, d.Err)
+	}
+	return fmt.Sprintf("%s Batch step %d (transactions %d-%d) claims state root %v, computed %v", optimisticrp.OPR_BANNER, d.Step, d.From, d.To, d.Claimed.Hex(), d.Computed.Hex())
+}
+
+type multiProver interface {
+	NewMultiProof([]common.Address) (*optimisticrp.MultiProof, error)
+}
+
+//FindDivergence replays the batch step by step, between its intermediate roots, and returns the first step that does not match or
+//whose transactions can be proven invalid
+//The state is left untouched, nil is returned if all the intermediate roots are valid
+func FindDivergence(state optimisticrp.Optimistic, signer optimisticrp.Signer, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw, batch optimisticrp.Batch) (*Divergence, error) {
+	snapshot := state.Snapshot()
+	defer state.RevertToSnapshot(snapshot)
+	if err := ApplyOnChainData(state, deposits, withdraws); err != nil {
+		return nil, err
+	}
+	from := 0
+	for step, ir := range batch.IntermediateRoots {
+		d := &Divergence{Step: step, From: from, To: int(ir.Index), PreStateRoot: state.StateRoot(), Claimed: ir.Root}
+		if ir.Index < uint64(from) || ir.Index >= uint64(len(batch.Transactions)) {
+			d.Err = ErrMalformedRoots
+			return d, nil
+		}
+		stepSnapshot := state.Snapshot()
+		for _, tx := range batch.Transactions[from : ir.Index+1] {
+			txSnapshot := state.Snapshot()
+			_, err := ProcessTx(state, signer, batch.Submitter, tx)
+			if Provable(err) {
+				d.Err = err
+				break
+			}
+			//the unprovable invalid transactions are skipped like in Replay
+			if err != nil {
+				state.RevertToSnapshot(txSnapshot)
+			} else {
+				state.DiscardSnapshot(txSnapshot)
+			}
+		}
+		if d.Err == nil {
+			d.Computed = state.StateRoot()
+			if d.Computed == ir.Root {
+				from = int(ir.Index) + 1
+				continue
+			}
+		}
+		//the proof is generated at the step pre-state
+		state.RevertToSnapshot(stepSnapshot)
+		if prover, ok := state.(multiProver); ok {
+			proof, err := prover.NewMultiProof(stepAccounts(batch, d.From, d.To))
+			if err != nil {
+				return nil, err
+			}
+			d.Proof = proof
+		}
+		return d, nil
+	}
+	return nil, nil
+}
+
+//stepAccounts returns the accounts touched by the transactions from..to, the batch submitter earns their fees
+func stepAccounts(batch optimisticrp.Batch, from, to int) []common.Address {
+	seen := map[common.Address]bool{batch.Submitter: true}
+	accounts := []common.Address{batch.Submitter}
+	for _, tx := range batch.Transactions[from : to+1] {
+		for _, addr := range append([]common.Address{tx.From}, tx.Recipients()...) {
+			if !seen[addr] {
+				seen[addr] = true
+				accounts = append(accounts, addr)
+			}
+		}
+	}
+	return accounts
+}
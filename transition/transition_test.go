@@ -0,0 +1,490 @@
+package transition
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rogercoll/optimisticrp"
+)
+
+var (
+	addrAccount1    = common.HexToAddress("0x048C82fe2C85956Cf2872FBe32bE4AD06de3Db1E")
+	addrAccount2    = common.HexToAddress("0x9185eAE1c5AD845137AaDf34a955e1D676fE421B")
+	addrAccount3    = common.HexToAddress("0x522fE0423db9de4e8Bb88aF3bF24aBE9B7dBF787")
+	privAccount1, _ = crypto.HexToECDSA("ff10aa6af851c1b49b7d3a94611d7823adbcfae76e153fc2757b4108a1dc402d")
+	privAccount2, _ = crypto.HexToECDSA("482254ce62c1473ccbf354bf33e08d71ff09dd2859e4fb8ae08d228fb8b727a5")
+	signer          = optimisticrp.NewRollupSigner(big.NewInt(1337), common.Address{})
+)
+
+func signTx(t *testing.T, tx optimisticrp.Transaction, privKey *ecdsa.PrivateKey) optimisticrp.Transaction {
+	if tx.Gas == nil {
+		tx.Gas = big.NewInt(0)
+	}
+	signedTx, err := optimisticrp.SignTx(&tx, signer, privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *signedTx
+}
+
+func newState(t *testing.T) optimisticrp.Optimistic {
+	tr, err := optimisticrp.NewMemoryDatabase().OpenHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+func balance(t *testing.T, state optimisticrp.Optimistic, addr common.Address) *big.Int {
+	acc, err := state.GetAccount(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc.Balance
+}
+
+func TestReplay(t *testing.T) {
+	state := newState(t)
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	withdraws := []optimisticrp.Withdraw{{From: addrAccount1, Value: big.NewInt(2)}}
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount2, To: addrAccount3, Value: big.NewInt(1)}, privAccount2),
+	}}
+	root, receipts, err := Replay(state, signer, deposits, withdraws, batch, Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != state.StateRoot() {
+		t.Errorf("StateRoot = %v; want %v", root, state.StateRoot())
+	}
+	if len(receipts) != 2 || receipts[1].StateRoot != root {
+		t.Errorf("Receipts = %v; want 2 receipts ending at %v", receipts, root)
+	}
+	for addr, want := range map[common.Address]int64{addrAccount1: 5, addrAccount2: 2, addrAccount3: 1} {
+		if got := balance(t, state, addr); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("Balance of %v = %v; want %v", addr, got, want)
+		}
+	}
+}
+
+func TestReplayRevertsFraud(t *testing.T) {
+	state := newState(t)
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount2, To: addrAccount3, Value: big.NewInt(5)}, privAccount2),
+	}}
+	_, _, err := Replay(state, signer, deposits, nil, batch, Pending)
+	fraud, ok := err.(*optimisticrp.InvalidBalance)
+	if !ok || fraud.Addr != addrAccount2 {
+		t.Fatalf("Error = %v; want InvalidBalance of %v", err, addrAccount2)
+	}
+	if got := balance(t, state, addrAccount1); got.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("Deposit must be kept after a failed batch, balance = %v", got)
+	}
+	if _, err := state.GetAccount(addrAccount2); err == nil {
+		t.Errorf("Transactions of a failed batch must be reverted")
+	}
+}
+
+func TestReplayReverted(t *testing.T) {
+	state := newState(t)
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+	}}
+	_, receipts, err := Replay(state, signer, deposits, nil, batch, Reverted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 0 {
+		t.Errorf("Transactions of a reverted batch must not be applied")
+	}
+	if got := balance(t, state, addrAccount1); got.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("Balance = %v; want %v", got, 10)
+	}
+}
+
+func TestReplaySkipsInvalidTransactions(t *testing.T) {
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	forged := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount3, Value: big.NewInt(4)}, privAccount2)
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+		forged,
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1), Nonce: 1}, privAccount1),
+	}}
+	for _, status := range []Status{Pending, Valid} {
+		state := newState(t)
+		root, receipts, err := Replay(state, signer, deposits, nil, batch, status)
+		if err != nil {
+			t.Fatalf("Replay of a %v batch with an invalid signature = %v; want the transaction skipped", status, err)
+		}
+		if len(receipts) != 3 || receipts[1].Err == nil || receipts[0].Err != nil || receipts[2].Err != nil {
+			t.Fatalf("Receipts = %v; want the second one skipped", receipts)
+		}
+		if _, ok := receipts[1].Err.(*optimisticrp.InvalidSignature); !ok || receipts[1].StateRoot != receipts[0].StateRoot || root != state.StateRoot() {
+			t.Errorf("Skipped receipt = %v; want InvalidSignature leaving the state at %v", receipts[1], receipts[0].StateRoot)
+		}
+		if got := balance(t, state, addrAccount2); got.Cmp(big.NewInt(4)) != 0 {
+			t.Errorf("Balance = %v; want 4", got)
+		}
+		if _, err := state.GetAccount(addrAccount3); err == nil {
+			t.Errorf("Skipped transaction must not create its recipient")
+		}
+	}
+	//a provable overdraft of a pending batch is returned to be proven, a valid batch skips it
+	batch.Transactions[1] = signTx(t, optimisticrp.Transaction{From: addrAccount2, To: addrAccount3, Value: big.NewInt(5)}, privAccount2)
+	state := newState(t)
+	if _, _, err := Replay(state, signer, deposits, nil, batch, Pending); !Provable(err) {
+		t.Errorf("Replay of a pending batch = %v; want a provable InvalidBalance", err)
+	}
+	if _, err := state.GetAccount(addrAccount2); err == nil {
+		t.Errorf("Transactions of a fraudulent pending batch must be reverted")
+	}
+	if _, receipts, err := Replay(newState(t), signer, deposits, nil, batch, Valid); err != nil || len(receipts) != 3 || !Provable(receipts[1].Err) {
+		t.Errorf("Replay of a valid batch = %v, %v; want the overdraft skipped", receipts, err)
+	}
+}
+
+func isInvalidValue(err error) bool {
+	_, ok := err.(*optimisticrp.InvalidValue)
+	return ok
+}
+
+func TestFundsValues(t *testing.T) {
+	state := newState(t)
+	if err := RemoveFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(1)); err == nil {
+		t.Errorf("Withdraw of a missing account must fail")
+	}
+	if _, err := state.GetAccount(addrAccount1); err == nil {
+		t.Errorf("Withdraw of a missing account must not create it")
+	}
+	for _, value := range []*big.Int{nil, big.NewInt(-1)} {
+		if err := AddFunds(state, addrAccount1, optimisticrp.Ether, value); !isInvalidValue(err) {
+			t.Errorf("AddFunds(%v) must return InvalidValue", value)
+		}
+	}
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := RemoveFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(11)).(*optimisticrp.InvalidBalance); !ok {
+		t.Errorf("Withdraw above the balance must return InvalidBalance")
+	}
+	//negative amounts can not be signed
+	for _, tx := range []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2}, privAccount1),
+		{From: addrAccount1, To: addrAccount2, Value: big.NewInt(-1)},
+		{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1), Gas: big.NewInt(-1)},
+	} {
+		if _, err := ProcessTx(state, signer, addrAccount3, tx); !isInvalidValue(err) {
+			t.Errorf("Transaction of value %v and fee %v must return InvalidValue", tx.Value, tx.Gas)
+		}
+	}
+	if got := balance(t, state, addrAccount1); got.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("Balance = %v; want 10", got)
+	}
+}
+
+func TestProcessSelfTransfer(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ProcessTx(state, signer, addrAccount3, signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount1, Value: big.NewInt(4)}, privAccount1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := state.GetAccount(addrAccount1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance.Cmp(big.NewInt(10)) != 0 || acc.Nonce != 1 {
+		t.Errorf("Account = %v; want balance 10 and nonce 1", acc)
+	}
+}
+
+func TestProcessTxSignature(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(4), Gas: big.NewInt(0)}
+	forged := signTx(t, unsigned, privAccount2)
+	for _, tx := range []optimisticrp.Transaction{unsigned, forged} {
+		_, err := ProcessTx(state, signer, addrAccount3, tx)
+		if _, ok := err.(*optimisticrp.InvalidSignature); !ok {
+			t.Errorf("Error = %v; want InvalidSignature", err)
+		}
+	}
+}
+
+func TestProcessTxNonce(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(1)}, privAccount1)
+	if _, err := ProcessTx(state, signer, addrAccount3, tx); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ProcessTx(state, signer, addrAccount3, tx)
+	if got, ok := err.(*optimisticrp.InvalidNonce); !ok || got.Expected != 1 || got.Got != 0 {
+		t.Errorf("Error = %v; want InvalidNonce expecting 1", err)
+	}
+}
+
+func TestReplayFees(t *testing.T) {
+	state := newState(t)
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	batch := optimisticrp.Batch{Submitter: addrAccount3, Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3), Gas: big.NewInt(2)}, privAccount1),
+	}}
+	_, receipts, err := Replay(state, signer, deposits, nil, batch, Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipts[0].Fee.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("Receipt fee = %v; want 2", receipts[0].Fee)
+	}
+	for addr, want := range map[common.Address]int64{addrAccount1: 5, addrAccount2: 3, addrAccount3: 2} {
+		if got := balance(t, state, addr); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("Balance of %v = %v; want %v", addr, got, want)
+		}
+	}
+}
+
+func TestProcessTxFeeBalance(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(9), Gas: big.NewInt(2)}, privAccount1)
+	_, err := ProcessTx(state, signer, addrAccount3, tx)
+	if _, ok := err.(*optimisticrp.InvalidBalance); !ok {
+		t.Errorf("Error = %v; want InvalidBalance as value + fee exceeds the balance", err)
+	}
+}
+
+func TestProcessTokenTx(t *testing.T) {
+	state := newState(t)
+	token := common.HexToAddress("0x0a")
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}, {From: addrAccount1, Value: big.NewInt(7), Token: token}}
+	if err := ApplyOnChainData(state, deposits, nil); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Token: token, Value: big.NewInt(4), Gas: big.NewInt(1)}, privAccount1)
+	if _, err := ProcessTx(state, signer, addrAccount3, tx); err != nil {
+		t.Fatal(err)
+	}
+	from, _ := state.GetAccount(addrAccount1)
+	to, _ := state.GetAccount(addrAccount2)
+	if from.BalanceOf(token).Cmp(big.NewInt(3)) != 0 || from.Balance.Cmp(big.NewInt(9)) != 0 {
+		t.Errorf("Sender = %v; want 3 tokens and 9 weis", from)
+	}
+	if to.BalanceOf(token).Cmp(big.NewInt(4)) != 0 || to.Balance.Sign() != 0 {
+		t.Errorf("Receiver = %v; want 4 tokens and no weis", to)
+	}
+	tx = signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Token: token, Value: big.NewInt(4), Nonce: 1}, privAccount1)
+	_, err := ProcessTx(state, signer, addrAccount3, tx)
+	if fraud, ok := err.(*optimisticrp.InvalidBalance); !ok || fraud.Token != token {
+		t.Errorf("Error = %v; want InvalidBalance of token %v", err, token)
+	}
+}
+
+func TestProcessWithdrawalTx(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: optimisticrp.WithdrawalAddress, Value: big.NewInt(4), Gas: big.NewInt(1)}, privAccount1)
+	root, err := ProcessTx(state, signer, addrAccount3, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := balance(t, state, addrAccount1); got.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Sender balance = %v; want 5", got)
+	}
+	if _, err := state.GetAccount(optimisticrp.WithdrawalAddress); err == nil {
+		t.Errorf("Withdrawals must not credit the withdrawal address")
+	}
+	receipt := optimisticrp.WithdrawalReceipt(addrAccount1, 0)
+	if tx.Recipient() != receipt || receipt == optimisticrp.WithdrawalReceipt(addrAccount1, 1) {
+		t.Fatalf("Withdrawal receipt must be derived from the sender and the nonce")
+	}
+	proof, err := optimisticrp.NewWithdrawalProof(state, addrAccount1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount, err := optimisticrp.VerifyWithdrawalProof(root, addrAccount1, 0, optimisticrp.Ether, proof[2])
+	if err != nil || amount.Cmp(big.NewInt(4)) != 0 {
+		t.Errorf("Claimable amount = %v, %v; want 4", amount, err)
+	}
+	if _, err := optimisticrp.VerifyWithdrawalProof(root, addrAccount2, 0, optimisticrp.Ether, proof[2]); err == nil {
+		t.Errorf("Withdrawal of another account must not be claimable")
+	}
+}
+
+func TestProcessTxType(t *testing.T) {
+	const burnTxType optimisticrp.TxType = 0x7e
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTx(t, optimisticrp.Transaction{Type: burnTxType, From: addrAccount1, Value: big.NewInt(4)}, privAccount1)
+	_, err := ProcessTx(state, signer, addrAccount3, tx)
+	if unknown, ok := err.(*optimisticrp.UnknownTxType); !ok || unknown.Type != burnTxType {
+		t.Fatalf("Error = %v; want UnknownTxType %d", err, burnTxType)
+	}
+	RegisterTxType(burnTxType, func(state optimisticrp.Optimistic, submitter common.Address, tx optimisticrp.Transaction) error {
+		return RemoveFunds(state, tx.From, optimisticrp.Ether, tx.Value)
+	})
+	if !KnownTxType(burnTxType) {
+		t.Fatalf("Registered type must be known")
+	}
+	if _, err := ProcessTx(state, signer, addrAccount3, tx); err != nil {
+		t.Fatal(err)
+	}
+	if got := balance(t, state, addrAccount1); got.Cmp(big.NewInt(6)) != 0 {
+		t.Errorf("Sender balance = %v; want 6", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registering a type twice must panic")
+		}
+	}()
+	RegisterTxType(optimisticrp.TransferTxType, processTransfer)
+}
+
+func TestProcessMultiSend(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	payments := []optimisticrp.Payment{{To: addrAccount2, Value: big.NewInt(3)}, {To: addrAccount3, Value: big.NewInt(2)}, {To: addrAccount2, Value: big.NewInt(1)}}
+	multiSend := func(payments []optimisticrp.Payment, nonce uint64) optimisticrp.Transaction {
+		tx, err := optimisticrp.NewMultiSendTx(addrAccount1, optimisticrp.Ether, payments, big.NewInt(1), nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signTx(t, *tx, privAccount1)
+	}
+	submitter := common.HexToAddress("0x0b")
+	if _, err := ProcessTx(state, signer, submitter, multiSend(payments, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for addr, want := range map[common.Address]int64{addrAccount1: 3, addrAccount2: 4, addrAccount3: 2, submitter: 1} {
+		if got := balance(t, state, addr); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("Balance of %v = %v; want %v", addr.Hex(), got, want)
+		}
+	}
+	//the sender can pay the first recipient but not all of them, nobody is paid
+	root := state.StateRoot()
+	_, err := ProcessTx(state, signer, submitter, multiSend([]optimisticrp.Payment{{To: addrAccount2, Value: big.NewInt(1)}, {To: addrAccount3, Value: big.NewInt(5)}}, 1))
+	if _, ok := err.(*optimisticrp.InvalidBalance); !ok {
+		t.Errorf("Error = %v; want InvalidBalance", err)
+	}
+	if state.StateRoot() != root {
+		t.Errorf("Invalid multi-send must not modify the state")
+	}
+	tx := multiSend(payments, 1)
+	tx.Value = big.NewInt(1)
+	_, err = ProcessTx(state, signer, submitter, signTx(t, tx, privAccount1))
+	if _, ok := err.(*optimisticrp.InvalidMultiSend); !ok {
+		t.Errorf("Error = %v; want InvalidMultiSend as the value is not the payments total", err)
+	}
+	accounts := stepAccounts(optimisticrp.Batch{Submitter: submitter, Transactions: []optimisticrp.Transaction{multiSend(payments, 1)}}, 0, 0)
+	if len(accounts) != 4 {
+		t.Errorf("Multi-send accounts = %v; want the submitter, sender and both recipients", accounts)
+	}
+}
+
+//The fee of a token multi-send is paid in ether like any other transaction, prove_fraud credits it to the submitter that can spend it in the same batch
+func TestReplayTokenMultiSendFee(t *testing.T) {
+	token := common.HexToAddress("0x0a11")
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}, {From: addrAccount1, Token: token, Value: big.NewInt(5)}}
+	multiSend, err := optimisticrp.NewMultiSendTx(addrAccount1, token, []optimisticrp.Payment{{To: addrAccount3, Value: big.NewInt(4)}}, big.NewInt(2), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := optimisticrp.Batch{Submitter: addrAccount2, Transactions: []optimisticrp.Transaction{
+		signTx(t, *multiSend, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount2, To: addrAccount3, Value: big.NewInt(2)}, privAccount2),
+	}}
+	state := newState(t)
+	_, receipts, err := Replay(state, signer, deposits, nil, batch, Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receipt := range receipts {
+		if receipt.Err != nil {
+			t.Errorf("Transaction %d skipped: %v", receipt.Index, receipt.Err)
+		}
+	}
+	for addr, want := range map[common.Address]int64{addrAccount1: 8, addrAccount2: 0, addrAccount3: 2} {
+		if got := balance(t, state, addr); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("Ether balance of %v = %v; want %v", addr.Hex(), got, want)
+		}
+	}
+}
+
+func TestReplaySparse(t *testing.T) {
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	batch := optimisticrp.Batch{Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+	}}
+	for _, state := range []optimisticrp.Optimistic{newState(t), optimisticrp.NewSparseMerkleTree()} {
+		if _, _, err := Replay(state, signer, deposits, nil, batch, Pending); err != nil {
+			t.Fatal(err)
+		}
+		if got := balance(t, state, addrAccount2); got.Cmp(big.NewInt(3)) != 0 {
+			t.Errorf("Balance = %v; want 3", got)
+		}
+	}
+}
+
+func TestFindDivergence(t *testing.T) {
+	deposits := []optimisticrp.Deposit{{From: addrAccount1, Value: big.NewInt(10)}}
+	batch := optimisticrp.Batch{Submitter: addrAccount3, Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3)}, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(2), Nonce: 1}, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount2, To: addrAccount1, Value: big.NewInt(1)}, privAccount2),
+	}}
+	_, receipts, err := Replay(newState(t), signer, deposits, nil, batch, Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receipt := range receipts {
+		batch.IntermediateRoots = append(batch.IntermediateRoots, optimisticrp.IntermediateRoot{Index: uint64(receipt.Index), Root: receipt.StateRoot})
+	}
+	state := newState(t)
+	root := state.StateRoot()
+	divergence, err := FindDivergence(state, signer, deposits, nil, batch)
+	if err != nil || divergence != nil {
+		t.Fatalf("Divergence = %v, %v; want none", divergence, err)
+	}
+	batch.IntermediateRoots[1].Root = common.Hash{1}
+	divergence, err = FindDivergence(state, signer, deposits, nil, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if divergence == nil || divergence.Step != 1 || divergence.From != 1 || divergence.To != 1 || divergence.PreStateRoot != receipts[0].StateRoot {
+		t.Fatalf("Divergence = %v; want step 1 on top of %v", divergence, receipts[0].StateRoot)
+	}
+	if divergence.Computed != receipts[1].StateRoot || divergence.Proof == nil {
+		t.Errorf("Divergence must carry the computed root and the pre-state proof")
+	}
+	accounts, err := optimisticrp.VerifyMultiProof(divergence.PreStateRoot, divergence.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accounts[addrAccount1] == nil || accounts[addrAccount1].Nonce != 1 {
+		t.Errorf("Proven sender = %v; want the pre-state account with nonce 1", accounts[addrAccount1])
+	}
+	if state.StateRoot() != root {
+		t.Errorf("FindDivergence must not modify the state")
+	}
+}
@@ -0,0 +1,92 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+)
+
+//InvalidStateRoot is found when a batch claims a different state root than the one computed from its witness
+type InvalidStateRoot struct {
+	Claimed  common.Hash
+	Computed common.Hash
+}
+
+func (e *InvalidStateRoot) Error() string {
+	return fmt.Sprintf("%s Batch claims state root %v, computed %v", optimisticrp.OPR_BANNER, e.Claimed.Hex(), e.Computed.Hex())
+}
+
+//MissingWitness is found when the witness does not prove an account touched by the batch
+type MissingWitness struct {
+	Addr common.Address
+}
+
+func (e *MissingWitness) Error() string {
+	return fmt.Sprintf("%s Witness does not prove account %v", optimisticrp.OPR_BANNER, e.Addr.Hex())
+}
+
+//NewWitness proves every account touched by the deposits, withdraws and transactions of the batch, the state must be at batch.PrevStateRoot
+//The witness is enough to recompute the batch state root without the accounts state, see VerifyWitness
+func NewWitness(state optimisticrp.Optimistic, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw, batch optimisticrp.Batch) (*optimisticrp.MultiProof, error) {
+	prover, ok := state.(multiProver)
+	if !ok {
+		return nil, fmt.Errorf("%s State does not support multiproofs", optimisticrp.OPR_BANNER)
+	}
+	return prover.NewMultiProof(batchAccounts(deposits, withdraws, batch))
+}
+
+//VerifyWitness applies the batch on top of the state proven by the witness and returns the computed state root, the accounts state is not needed
+//The witness must be taken at batch.PrevStateRoot and prove all the touched accounts. A batch whose StateRoot differs from the computed one returns InvalidStateRoot
+//The transactions are replayed with the status of the batch like the nodes do (see Replay), so they compute the same root
+func VerifyWitness(signer optimisticrp.Signer, witness *optimisticrp.MultiProof, deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw, batch optimisticrp.Batch, status Status) (common.Hash, []Receipt, error) {
+	proven, err := optimisticrp.VerifyMultiProof(batch.PrevStateRoot, witness)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	//trie paths of unproven accounts are missing, reading them would look like an absent account
+	for _, addr := range batchAccounts(deposits, withdraws, batch) {
+		if _, ok := proven[addr]; !ok {
+			return common.Hash{}, nil, &MissingWitness{addr}
+		}
+	}
+	state, err := optimisticrp.OpenMultiProof(witness)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	stateRoot, receipts, err := Replay(state, signer, deposits, withdraws, batch, status)
+	if err != nil {
+		return stateRoot, nil, err
+	}
+	if stateRoot != batch.StateRoot {
+		return stateRoot, receipts, &InvalidStateRoot{batch.StateRoot, stateRoot}
+	}
+	return stateRoot, receipts, nil
+}
+
+//batchAccounts returns the accounts touched by the deposits, withdraws and transactions of the batch, in order of appearance
+func batchAccounts(deposits []optimisticrp.Deposit, withdraws []optimisticrp.Withdraw, batch optimisticrp.Batch) []common.Address {
+	var accounts []common.Address
+	for _, deposit := range deposits {
+		accounts = append(accounts, deposit.From)
+	}
+	for _, withdraw := range withdraws {
+		accounts = append(accounts, withdraw.From)
+	}
+	if len(batch.Transactions) > 0 {
+		accounts = append(accounts, stepAccounts(batch, 0, len(batch.Transactions)-1)...)
+	}
+	return uniqueAccounts(accounts)
+}
+
+func uniqueAccounts(accounts []common.Address) []common.Address {
+	seen := make(map[common.Address]bool)
+	unique := accounts[:0]
+	for _, addr := range accounts {
+		if !seen[addr] {
+			seen[addr] = true
+			unique = append(unique, addr)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,92 @@
+package transition
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rogercoll/optimisticrp"
+)
+
+func TestVerifyWitness(t *testing.T) {
+	state := newState(t)
+	for i := 0; i < 100; i++ {
+		if err := AddFunds(state, common.BigToAddress(big.NewInt(int64(i+1))), optimisticrp.Ether, big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	deposits := []optimisticrp.Deposit{{From: addrAccount2, Value: big.NewInt(5)}}
+	withdraws := []optimisticrp.Withdraw{{From: common.BigToAddress(big.NewInt(7)), Value: big.NewInt(1)}}
+	batch := optimisticrp.Batch{PrevStateRoot: state.StateRoot(), Submitter: addrAccount3, Transactions: []optimisticrp.Transaction{
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3), Gas: big.NewInt(1)}, privAccount1),
+		signTx(t, optimisticrp.Transaction{From: addrAccount2, To: common.Address{0xaa}, Value: big.NewInt(4)}, privAccount2),
+	}}
+	witness, err := NewWitness(state, deposits, withdraws, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.StateRoot, _, err = Replay(state, signer, deposits, withdraws, batch, Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateRoot, receipts, err := VerifyWitness(signer, witness, deposits, withdraws, batch, Pending)
+	if err != nil || stateRoot != batch.StateRoot || len(receipts) != 2 {
+		t.Fatalf("VerifyWitness = %v, %d receipts, %v; want %v", stateRoot.Hex(), len(receipts), err, batch.StateRoot.Hex())
+	}
+	claimed := batch
+	claimed.StateRoot = common.Hash{1}
+	if _, _, err := VerifyWitness(signer, witness, deposits, withdraws, claimed, Pending); err == nil {
+		t.Errorf("Invalid state root must be rejected")
+	} else if _, ok := err.(*InvalidStateRoot); !ok {
+		t.Errorf("Error = %v; want InvalidStateRoot", err)
+	}
+	partial := *witness
+	partial.Keys = partial.Keys[1:]
+	if _, _, err := VerifyWitness(signer, &partial, deposits, withdraws, batch, Pending); err == nil {
+		t.Errorf("Witness without a touched account must be rejected")
+	} else if _, ok := err.(*MissingWitness); !ok {
+		t.Errorf("Error = %v; want MissingWitness", err)
+	}
+	other := batch
+	other.PrevStateRoot = batch.StateRoot
+	if _, _, err := VerifyWitness(signer, witness, deposits, withdraws, other, Pending); err == nil {
+		t.Errorf("Witness of another state root must be rejected")
+	}
+}
+
+func TestVerifyWitnessSkipsInvalidTransactions(t *testing.T) {
+	state := newState(t)
+	if err := AddFunds(state, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	forged := signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(5)}, privAccount2)
+	batch := optimisticrp.Batch{PrevStateRoot: state.StateRoot(), Submitter: addrAccount3, Transactions: []optimisticrp.Transaction{
+		forged,
+		signTx(t, optimisticrp.Transaction{From: addrAccount1, To: addrAccount2, Value: big.NewInt(3), Gas: big.NewInt(1)}, privAccount1),
+	}}
+	witness, err := NewWitness(state, nil, nil, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []Status{Valid, Pending} {
+		full := newState(t)
+		if err := AddFunds(full, addrAccount1, optimisticrp.Ether, big.NewInt(10)); err != nil {
+			t.Fatal(err)
+		}
+		root, _, err := Replay(full, signer, nil, nil, batch, status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch.StateRoot = root
+		stateRoot, receipts, err := VerifyWitness(signer, witness, nil, nil, batch, status)
+		if err != nil || stateRoot != root {
+			t.Fatalf("VerifyWitness of a %v batch = %v, %v; want the replayed root %v", status, stateRoot.Hex(), err, root.Hex())
+		}
+		if len(receipts) != 2 || receipts[0].Err == nil || receipts[1].Err != nil {
+			t.Errorf("Receipts = %+v; want the forged transaction skipped", receipts)
+		}
+	}
+}
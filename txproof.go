@@ -0,0 +1,95 @@
+package optimisticrp
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+//TxProof proves that a transaction is at position Index of the batch whose transactions root is TxRoot
+type TxProof struct {
+	TxRoot common.Hash
+	Index  uint64
+	Nodes  [][]byte //trie nodes from the root to the transaction
+}
+
+//txTrie returns the trie of the batch transactions keyed by the RLP encoding of their position, the same layout than Ethereum block transactions
+func (b *Batch) txTrie() (*trie.Trie, error) {
+	t, err := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		return nil, err
+	}
+	for i := range b.Transactions {
+		key, _ := rlp.EncodeToBytes(uint64(i))
+		value, err := b.Transactions[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		t.Update(key, value)
+	}
+	return t, nil
+}
+
+//ComputeTxRoot returns the root of the batch transactions trie, a batch without transactions has the empty root
+func (b *Batch) ComputeTxRoot() (common.Hash, error) {
+	t, err := b.txTrie()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return t.Hash(), nil
+}
+
+//TxIndex returns the position of the transaction with the given hash in the batch
+func (b *Batch) TxIndex(hash common.Hash) (int, error) {
+	for i := range b.Transactions {
+		if b.Transactions[i].Hash() == hash {
+			return i, nil
+		}
+	}
+	return 0, &TransactionNotFound{hash}
+}
+
+//NewTxProof proves the inclusion of the transaction at position index of the batch
+func (b *Batch) NewTxProof(index int) (*TxProof, error) {
+	if index < 0 || index >= len(b.Transactions) {
+		return nil, &TransactionNotFound{}
+	}
+	t, err := b.txTrie()
+	if err != nil {
+		return nil, err
+	}
+	key, _ := rlp.EncodeToBytes(uint64(index))
+	var proof proofList
+	if err := t.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	return &TxProof{TxRoot: t.Hash(), Index: uint64(index), Nodes: proof}, nil
+}
+
+func (p *TxProof) MarshalBinary() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+func UnMarshalTxProof(b []byte) (*TxProof, error) {
+	var p TxProof
+	err := rlp.DecodeBytes(b, &p)
+	return &p, err
+}
+
+//VerifyTxProof checks offline the proof against the transactions root of a batch and returns the proven transaction
+func VerifyTxProof(txRoot common.Hash, p *TxProof) (*Transaction, error) {
+	if p.TxRoot != txRoot {
+		return nil, &InvalidProof{Root: txRoot}
+	}
+	key, _ := rlp.EncodeToBytes(p.Index)
+	val, err := trie.VerifyProof(txRoot, key, newProofDB(p.Nodes))
+	if err != nil || len(val) == 0 {
+		return nil, &InvalidProof{Root: txRoot}
+	}
+	var tx Transaction
+	if err := tx.UnmarshalBinary(val); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
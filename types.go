@@ -2,9 +2,11 @@ package optimisticrp
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"encoding/binary"
 
@@ -12,7 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -24,10 +26,93 @@ type AccountNotFound struct {
 
 type InvalidBalance struct {
 	Addr  common.Address
+	Token common.Address //asset without enough funds, Ether for the native currency
 	Total *big.Int
 }
 
+type InvalidNonce struct {
+	Addr     common.Address
+	Expected uint64
+	Got      uint64
+}
+
+func (e *InvalidNonce) Error() string {
+	return fmt.Sprintf("%s Account %v expected nonce %v, got %v", OPR_BANNER, e.Addr, e.Expected, e.Got)
+}
+
+//InvalidValue is an amount that is missing or negative, it would move the funds the other way
+type InvalidValue struct {
+	Addr  common.Address
+	Value *big.Int
+}
+
+func (e *InvalidValue) Error() string {
+	return fmt.Sprintf("%s Invalid amount %v for account %v", OPR_BANNER, e.Value, e.Addr)
+}
+
+type InvalidSignature struct {
+	Addr common.Address
+}
+
+func (e *InvalidSignature) Error() string {
+	return fmt.Sprintf("%s Transaction from %v is not signed by its sender", OPR_BANNER, e.Addr)
+}
+
+type AccountFound struct {
+	Addr common.Address
+}
+
+func (e *AccountFound) Error() string {
+	return fmt.Sprintf("%s Account %v is in the trie, its absence can not be proven", OPR_BANNER, e.Addr)
+}
+
+type InvalidProof struct {
+	Addr common.Address
+	Root common.Hash
+}
+
+func (e *InvalidProof) Error() string {
+	return fmt.Sprintf("%s Invalid proof for account %v at state root %v", OPR_BANNER, e.Addr, e.Root.Hex())
+}
+
+//InvalidTxRoot is a batch whose calldata transactions root does not match its transactions
+type InvalidTxRoot struct {
+	Claimed  common.Hash
+	Computed common.Hash
+}
+
+func (e *InvalidTxRoot) Error() string {
+	return fmt.Sprintf("%s Batch transactions root %v does not match its transactions (%v)", OPR_BANNER, e.Claimed.Hex(), e.Computed.Hex())
+}
+
+type StateNotFound struct {
+	Root common.Hash
+}
+
+type BatchNotFound struct {
+	Batch uint64
+}
+
+type TransactionNotFound struct {
+	Hash common.Hash
+}
+
+func (e *TransactionNotFound) Error() string {
+	return fmt.Sprintf("%s Transaction %v is not in the batch", OPR_BANNER, e.Hash.Hex())
+}
+
+func (e *StateNotFound) Error() string {
+	return fmt.Sprintf("%s State root %v was never committed", OPR_BANNER, e.Root.Hex())
+}
+
+func (e *BatchNotFound) Error() string {
+	return fmt.Sprintf("%s No state was committed for batch %v", OPR_BANNER, e.Batch)
+}
+
 func (i *InvalidBalance) Error() string {
+	if i.Token != Ether {
+		return fmt.Sprintf("%s Account %v has not enough funds of token %v (%v)", OPR_BANNER, i.Addr, i.Token.Hex(), i.Total)
+	}
 	return fmt.Sprintf("%s Account %v has not enough funds (%v)", OPR_BANNER, i.Addr, i.Total)
 }
 
@@ -40,6 +125,33 @@ type Optimistic interface {
 	GetAccount(common.Address) (Account, error)
 	UpdateAccount(common.Address, Account) common.Hash
 	NewProve(common.Address) ([][]byte, error)
+	//Snapshot returns an identifier of the current state, RevertToSnapshot undoes every update done since then
+	Snapshot() int
+	RevertToSnapshot(int)
+	//DiscardSnapshot releases a snapshot that will not be reverted, and the ones taken after it
+	DiscardSnapshot(int)
+}
+
+//Resetter is implemented by the Optimistic states that can be emptied to be computed again from scratch
+type Resetter interface {
+	Reset()
+}
+
+//Committer is implemented by the Optimistic states that can persist their nodes, nodes call it after every processed batch
+type Committer interface {
+	CommitBatch(batch uint64) (common.Hash, error)
+	//Head returns the last committed state root and its batch number
+	Head() (Head, error)
+}
+
+//Checkpointer is implemented by the Optimistic states that persist the last processed layer 1 block, nodes resume the on-chain data ingestion from it
+type Checkpointer interface {
+	//Checkpoints returns the persisted checkpoints from the oldest to the newest block
+	Checkpoints() ([]*Checkpoint, error)
+	//WriteCheckpoint persists the newest checkpoint, the ones of later blocks are deleted
+	WriteCheckpoint(*Checkpoint) error
+	//ResetTo opens the state at a committed root, the uncommitted updates are discarded
+	ResetTo(common.Hash) error
 }
 type Signer interface {
 	// SignatureValues returns the raw R, S, V values corresponding to the
@@ -60,8 +172,15 @@ type Aggregator interface {
 type OptimisticSContract interface {
 	OriAddr() common.Address
 	GetStateRoot() (common.Hash, error)
-	GetOnChainData(chan<- interface{})
-	GetPendingDeposits(chan<- interface{})
+	ChainID() (*big.Int, error)
+	//GetOnChainData sends the events of the layer 1 blocks [from, to] in order, each block followed by its L1Block
+	//It returns the first error, or the context one once cancelled, and the events are not sent after it. Read it with StreamOnChainData
+	GetOnChainData(ctx context.Context, from, to uint64, events chan<- Event) error
+	//ConfirmedHead returns the number and hash of the last layer 1 block with the required confirmations
+	ConfirmedHead() (uint64, common.Hash, error)
+	BlockHash(uint64) (common.Hash, error)
+	//GetPendingDeposits sends the DepositEvents after the last batch in order, like GetOnChainData
+	GetPendingDeposits(ctx context.Context, events chan<- Event) error
 	IsStateRootValid(common.Hash) (bool, error)
 	PrepareTxOptions(*big.Int, *big.Int, *big.Int, *ecdsa.PrivateKey) (*bind.TransactOpts, error)
 	NewBatch(SolidityBatch, *bind.TransactOpts) (*types.Transaction, error)
@@ -69,28 +188,46 @@ type OptimisticSContract interface {
 	Bond(*bind.TransactOpts) (*types.Transaction, error)
 	Deposit(*bind.TransactOpts) (*types.Transaction, error)
 	Withdraw(*bind.TransactOpts, []byte, []byte, []byte, []byte) (*types.Transaction, error)
-	Client() *ethclient.Client
 }
 
 //Common types
+
+//Ether identifies the layer 1 native currency, any other asset is identified by its ERC20 contract address
+var Ether = common.Address{}
+
+//WithdrawalAddress is the receiver of withdrawal transactions: the value is burnt from the sender and left in its withdrawal receipt account,
+//which the sender claims on layer 1 (claimWithdrawal or claimTokenWithdrawal)
+var WithdrawalAddress = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+
+//WithdrawalReceipt returns the account that holds the funds burnt by the withdrawal transaction of from with the given nonce,
+//the contract derives it as address(keccak256(abi.encodePacked(from, uint256(nonce))))
+func WithdrawalReceipt(from common.Address, nonce uint64) common.Address {
+	return common.BytesToAddress(crypto.Keccak256(from.Bytes(), common.BigToHash(new(big.Int).SetUint64(nonce)).Bytes()))
+}
+
 type Deposit struct {
 	From  common.Address
 	Value *big.Int
+	Token common.Address
 }
 
 type Withdraw struct {
 	From  common.Address
 	Value *big.Int
+	Token common.Address
 }
 
 //To, from ID in the AccountsTrie
 type Transaction struct {
 	Value   *big.Int // wei amount
-	Gas     *big.Int // gasLimit
+	Gas     *big.Int // fee in weis paid to the batch submitter
 	To      common.Address
 	From    common.Address
 	Nonce   uint64
-	V, R, S *big.Int // signature values
+	V, R, S *big.Int       // signature values
+	Token   common.Address // transferred asset, fees are always paid in ether
+	Type    TxType         // kind of transaction, transfers keep the legacy RLP list encoding (see envelope.go)
+	Data    []byte         // kind specific payload, empty for transfers
 }
 
 type SolidityTransaction struct {
@@ -100,33 +237,67 @@ type SolidityTransaction struct {
 	From    common.Address
 	Nonce   uint64
 	V, R, S *big.Int // signature values TODO => make signature verificable on-chain
+	Token   common.Address
+	Type    TxType
+	Data    []byte
 }
 
 type Account struct {
 	Nonce   uint64
-	Balance *big.Int //weis
+	Balance *big.Int       //weis
+	Tokens  []TokenBalance //ERC20 balances sorted by token address, empty balances are not stored
 }
 
+type TokenBalance struct {
+	Token   common.Address
+	Balance *big.Int
+}
+
+//SolidityAccount is the trie encoding of an account: [nonce, balance, [token, balance]...], accounts without tokens keep the [nonce, balance] layout read by the contract
 type SolidityAccount struct {
 	Nonce   uint64
 	Balance []byte
+	Tokens  []SolidityTokenBalance `rlp:"tail"`
+}
+
+type SolidityTokenBalance struct {
+	Token   common.Address
+	Balance []byte
 }
 
 type Batch struct {
 	PrevStateRoot common.Hash
 	StateRoot     common.Hash
 	Transactions  []Transaction
+	//root of the transactions trie (see ComputeTxRoot), EncodeBatch sends it in the calldata and DecodeBatch checks it
+	TxRoot    common.Hash    `rlp:"-"`
+	Submitter common.Address `rlp:"-"` //aggregator that sent the batch on-chain, it is not part of the calldata
+	//optional post-state roots of some transactions, batches without them keep the previous encoding
+	IntermediateRoots []IntermediateRoot `rlp:"tail"`
 }
 
 type SolidityBatch struct {
-	PrevStateRoot common.Hash
-	StateRoot     common.Hash
-	Transactions  []SolidityTransaction
+	PrevStateRoot     common.Hash
+	StateRoot         common.Hash
+	Transactions      []SolidityTransaction
+	TxRoot            common.Hash
+	Submitter         common.Address     `rlp:"-"`
+	IntermediateRoots []IntermediateRoot `rlp:"tail"`
 }
 
+//IntermediateRoot is the accounts state root right after the transaction at position Index of the batch
+type IntermediateRoot struct {
+	Index uint64
+	Root  common.Hash
+}
+
+//encodeTyped writes the binary envelope: the RLP list of transfers or the type byte followed by the RLP payload
 func (tx *Transaction) encodeTyped(w *bytes.Buffer) error {
-	//w.WriteByte(tx.Type())
-	return rlp.Encode(w, tx)
+	if tx.Type == TransferTxType {
+		return rlp.Encode(w, tx)
+	}
+	w.WriteByte(byte(tx.Type))
+	return rlp.Encode(w, tx.typedFields())
 }
 
 func (bt *Batch) encodeTyped(w *bytes.Buffer) error {
@@ -142,16 +313,80 @@ func (bt *Batch) MarshalBinary() ([]byte, error) {
 
 func UnMarshalBatch(b []byte) (*Batch, error) {
 	var data Batch
-	err := rlp.DecodeBytes(b, &data)
+	if err := rlp.DecodeBytes(b, &data); err != nil {
+		return &data, err
+	}
+	txRoot, err := data.ComputeTxRoot()
+	data.TxRoot = txRoot
 	return &data, err
 }
 
+//Fee returns the weis paid by the sender to the aggregator that submits the transaction, it is the Gas field
+func (tx *Transaction) Fee() *big.Int {
+	if tx.Gas == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(tx.Gas)
+}
+
+//Cost returns the weis debited from the sender: value + fee for ether transfers, only the fee for token transfers
+func (tx *Transaction) Cost() *big.Int {
+	cost := tx.Fee()
+	if tx.Value != nil && tx.Token == Ether {
+		cost.Add(cost, tx.Value)
+	}
+	return cost
+}
+
+//IsWithdrawal reports whether the transaction burns its value to exit it to layer 1
+func (tx *Transaction) IsWithdrawal() bool {
+	return tx.To == WithdrawalAddress
+}
+
+//Recipient returns the account credited with the transaction value, the withdrawal receipt for withdrawal transactions
+func (tx *Transaction) Recipient() common.Address {
+	if tx.IsWithdrawal() {
+		return WithdrawalReceipt(tx.From, tx.Nonce)
+	}
+	return tx.To
+}
+
+//Recipients returns every account credited by the transaction: its Recipient or the payments of a multi-send
+//Malformed multi-sends credit nobody
+func (tx *Transaction) Recipients() []common.Address {
+	if tx.Type != MultiSendTxType {
+		return []common.Address{tx.Recipient()}
+	}
+	payments, err := tx.Payments()
+	if err != nil {
+		return nil
+	}
+	recipients := make([]common.Address, len(payments))
+	for i, payment := range payments {
+		recipients[i] = payment.To
+	}
+	return recipients
+}
+
+//Hash returns the keccak256 hash of the RLP encoded signed transaction, it uniquely identifies the transaction
+func (tx *Transaction) Hash() common.Hash {
+	return rlpHash(tx)
+}
+
 func (tx *Transaction) MarshalBinary() ([]byte, error) {
 	var buf bytes.Buffer
 	err := tx.encodeTyped(&buf)
 	return buf.Bytes(), err
 }
 
+//UnmarshalBinary decodes the envelope written by MarshalBinary
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] >= 0xc0 {
+		return rlp.DecodeBytes(b, tx)
+	}
+	return tx.decodeTyped(b)
+}
+
 func (account *Account) MarshalBinary() []byte {
 	//Uint64 will occupy a byte array of length 8
 	b := make([]byte, 8)
@@ -161,19 +396,67 @@ func (account *Account) MarshalBinary() []byte {
 	return append(b, bb...)
 }
 
+//BalanceOf returns a copy of the account balance of the given asset, zero if the account does not hold it
+func (account *Account) BalanceOf(asset common.Address) *big.Int {
+	if asset == Ether {
+		return new(big.Int).Set(account.Balance)
+	}
+	if i, found := account.tokenIndex(asset); found {
+		return new(big.Int).Set(account.Tokens[i].Balance)
+	}
+	return new(big.Int)
+}
+
+//SetBalanceOf sets the account balance of the given asset, tokens are kept sorted so the account encoding is canonical
+func (account *Account) SetBalanceOf(asset common.Address, value *big.Int) {
+	if asset == Ether {
+		account.Balance = new(big.Int).Set(value)
+		return
+	}
+	i, found := account.tokenIndex(asset)
+	switch {
+	case found && value.Sign() == 0:
+		account.Tokens = append(account.Tokens[:i:i], account.Tokens[i+1:]...)
+	case found:
+		account.Tokens[i].Balance = new(big.Int).Set(value)
+	case value.Sign() != 0:
+		tokens := make([]TokenBalance, 0, len(account.Tokens)+1)
+		tokens = append(append(tokens, account.Tokens[:i]...), TokenBalance{asset, new(big.Int).Set(value)})
+		account.Tokens = append(tokens, account.Tokens[i:]...)
+	}
+}
+
+func (account *Account) tokenIndex(token common.Address) (int, bool) {
+	i := sort.Search(len(account.Tokens), func(i int) bool {
+		return bytes.Compare(account.Tokens[i].Token.Bytes(), token.Bytes()) >= 0
+	})
+	return i, i < len(account.Tokens) && account.Tokens[i].Token == token
+}
+
 func (account *Account) SolidityFormat() interface{} {
-	return SolidityAccount{Nonce: account.Nonce, Balance: math.U256Bytes(account.Balance)}
+	sa := SolidityAccount{Nonce: account.Nonce, Balance: math.U256Bytes(account.Balance)}
+	for _, tb := range account.Tokens {
+		sa.Tokens = append(sa.Tokens, SolidityTokenBalance{tb.Token, math.U256Bytes(new(big.Int).Set(tb.Balance))})
+	}
+	return sa
 }
 
 func (account *SolidityAccount) ToGolangFormat() (Account, error) {
-	return Account{Nonce: account.Nonce, Balance: new(big.Int).SetBytes(account.Balance)}, nil
+	acc := Account{Nonce: account.Nonce, Balance: new(big.Int).SetBytes(account.Balance)}
+	for _, tb := range account.Tokens {
+		acc.Tokens = append(acc.Tokens, TokenBalance{tb.Token, new(big.Int).SetBytes(tb.Balance)})
+	}
+	return acc, nil
 }
 
 func (b *Batch) SolidityFormat() SolidityBatch {
 	sb := SolidityBatch{
 		PrevStateRoot: b.PrevStateRoot,
 		StateRoot:     b.StateRoot,
+		TxRoot:        b.TxRoot,
+		Submitter:     b.Submitter,
 	}
+	sb.IntermediateRoots = append(sb.IntermediateRoots, b.IntermediateRoots...)
 	for _, tx := range b.Transactions {
 		sb.Transactions = append(sb.Transactions, SolidityTransaction{
 			Gas:   math.U256Bytes(tx.Gas),
@@ -184,6 +467,9 @@ func (b *Batch) SolidityFormat() SolidityBatch {
 			V:     tx.V,
 			R:     tx.R,
 			S:     tx.S,
+			Token: tx.Token,
+			Type:  tx.Type,
+			Data:  tx.Data,
 		})
 	}
 	return sb
@@ -193,7 +479,10 @@ func (sb *SolidityBatch) ToGolangFormat() (Batch, error) {
 	b := Batch{
 		PrevStateRoot: sb.PrevStateRoot,
 		StateRoot:     sb.StateRoot,
+		TxRoot:        sb.TxRoot,
+		Submitter:     sb.Submitter,
 	}
+	b.IntermediateRoots = append(b.IntermediateRoots, sb.IntermediateRoots...)
 	for _, tx := range sb.Transactions {
 		b.Transactions = append(b.Transactions, Transaction{
 			Gas:   new(big.Int).SetBytes(tx.Gas),
@@ -204,6 +493,9 @@ func (sb *SolidityBatch) ToGolangFormat() (Batch, error) {
 			V:     tx.V,
 			R:     tx.R,
 			S:     tx.S,
+			Token: tx.Token,
+			Type:  tx.Type,
+			Data:  tx.Data,
 		})
 	}
 	return b, nil
@@ -235,6 +527,9 @@ func (tx *Transaction) copy() *Transaction {
 		To:    tx.To,
 		From:  tx.From,
 		Nonce: tx.Nonce,
+		Token: tx.Token,
+		Type:  tx.Type,
+		Data:  common.CopyBytes(tx.Data),
 		V:     new(big.Int),
 		R:     new(big.Int),
 		S:     new(big.Int),
@@ -1,13 +1,22 @@
 package optimisticrp
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
 	"math/big"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 var Accounts = []Account{
-	{1, big.NewInt(2)},
-	{3, big.NewInt(4)},
+	{Nonce: 1, Balance: big.NewInt(2)},
+	{Nonce: 3, Balance: big.NewInt(4)},
 }
 
 func TestMarshallAccount(t *testing.T) {
@@ -23,3 +32,321 @@ func TestMarshallAccount(t *testing.T) {
 		}
 	}
 }
+
+func TestAccountTokens(t *testing.T) {
+	tokenA := common.HexToAddress("0x0a")
+	tokenB := common.HexToAddress("0x0b")
+	acc := Account{Nonce: 1, Balance: big.NewInt(2)}
+	oldFormat, err := rlp.EncodeToBytes([]interface{}{uint64(1), common.LeftPadBytes([]byte{2}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := rlp.EncodeToBytes(acc.SolidityFormat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, oldFormat) {
+		t.Errorf("Accounts without tokens must keep the [nonce, balance] encoding")
+	}
+	acc.SetBalanceOf(tokenB, big.NewInt(5))
+	acc.SetBalanceOf(tokenA, big.NewInt(3))
+	if len(acc.Tokens) != 2 || acc.Tokens[0].Token != tokenA {
+		t.Errorf("Tokens = %v; want them sorted by address", acc.Tokens)
+	}
+	enc, err = rlp.EncodeToBytes(acc.SolidityFormat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SolidityAccount
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := decoded.ToGolangFormat()
+	if got.BalanceOf(tokenA).Cmp(big.NewInt(3)) != 0 || got.BalanceOf(tokenB).Cmp(big.NewInt(5)) != 0 || got.BalanceOf(Ether).Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("Decoded account = %v; want %v", got, acc)
+	}
+	acc.SetBalanceOf(tokenA, new(big.Int))
+	if len(acc.Tokens) != 1 || acc.Tokens[0].Token != tokenB {
+		t.Errorf("Empty token balances must be removed, tokens = %v", acc.Tokens)
+	}
+}
+
+//testBatch returns a batch of n transfers signed by three random accounts
+func testBatch(t *testing.T, n int) Batch {
+	signer := NewRollupSigner(big.NewInt(1337), common.Address{})
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	batch := Batch{PrevStateRoot: common.Hash{1}, StateRoot: common.Hash{2}}
+	for i := 0; i < n; i++ {
+		tx := Transaction{
+			Value: big.NewInt(int64(i) * 1e15),
+			Gas:   big.NewInt(1e12),
+			From:  crypto.PubkeyToAddress(keys[i%3].PublicKey),
+			To:    crypto.PubkeyToAddress(keys[(i+1)%3].PublicKey),
+			Nonce: uint64(i / 3),
+		}
+		signed, err := SignTx(&tx, signer, keys[i%3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch.Transactions = append(batch.Transactions, *signed)
+	}
+	return batch
+}
+
+func TestBatchCodec(t *testing.T) {
+	batch := testBatch(t, 100)
+	txRoot, err := batch.ComputeTxRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make(map[byte]int)
+	for _, version := range []byte{LegacyBatchVersion, CompactBatchVersion, DeflateBatchVersion} {
+		enc, err := EncodeBatch(&batch, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[version] = len(enc)
+		decoded, err := DecodeBatch(enc)
+		if err != nil {
+			t.Fatalf("Version %v: %v", version, err)
+		}
+		if decoded.StateRoot != batch.StateRoot || len(decoded.Transactions) != len(batch.Transactions) {
+			t.Fatalf("Version %v: decoded batch = %v; want %v", version, decoded, batch)
+		}
+		for i, tx := range decoded.Transactions {
+			if tx.Hash() != batch.Transactions[i].Hash() {
+				t.Errorf("Version %v: transaction %d = %v; want %v", version, i, tx, batch.Transactions[i])
+			}
+		}
+		if decoded.TxRoot != txRoot {
+			t.Errorf("Version %v: TxRoot = %v; want %v", version, decoded.TxRoot.Hex(), txRoot.Hex())
+		}
+	}
+	if sizes[CompactBatchVersion] >= sizes[LegacyBatchVersion] || sizes[DeflateBatchVersion] >= sizes[CompactBatchVersion] {
+		t.Errorf("Batch sizes = %v; each version must be smaller than the previous one", sizes)
+	}
+	if _, err := DecodeBatch([]byte{0xc3, 0x80, 0x80, 0x81}); err == nil {
+		t.Errorf("Batch without transactions item must not be decoded")
+	}
+	//the calldata transactions root must match the transactions
+	sb := batch.SolidityFormat()
+	sb.TxRoot = common.Hash{1}
+	enc, err := rlp.EncodeToBytes(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeBatch(enc); err == nil {
+		t.Errorf("Batch with another transactions root must not be decoded")
+	} else if e, ok := err.(*InvalidTxRoot); !ok || e.Computed != txRoot {
+		t.Errorf("Error = %v; want InvalidTxRoot computing %v", err, txRoot.Hex())
+	}
+}
+
+func TestTxProof(t *testing.T) {
+	batch := testBatch(t, 130)
+	txRoot, err := batch.ComputeTxRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emptyRoot, err := (&Batch{}).ComputeTxRoot(); err != nil || emptyRoot != types.EmptyRootHash {
+		t.Errorf("Empty batch TxRoot must be the empty trie root")
+	}
+	for _, i := range []int{0, 1, 17, 127, 128, 129} {
+		index, err := batch.TxIndex(batch.Transactions[i].Hash())
+		if err != nil || index != i {
+			t.Fatalf("TxIndex = %v, %v; want %v", index, err, i)
+		}
+		proof, err := batch.NewTxProof(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnMarshalTxProof(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := VerifyTxProof(txRoot, decoded)
+		if err != nil {
+			t.Fatalf("Transaction %d: %v", i, err)
+		}
+		if tx.Hash() != batch.Transactions[i].Hash() {
+			t.Errorf("Proven transaction = %v; want %v", tx, batch.Transactions[i])
+		}
+		decoded.Index++
+		if tx, err := VerifyTxProof(txRoot, decoded); err == nil && tx.Hash() == batch.Transactions[i].Hash() {
+			t.Errorf("Transaction %d proven at position %d", i, decoded.Index)
+		}
+	}
+	if _, err := VerifyTxProof(common.Hash{1}, &TxProof{TxRoot: txRoot}); err == nil {
+		t.Errorf("Proof of another batch must be rejected")
+	}
+	if _, err := batch.TxIndex(common.Hash{1}); err == nil {
+		t.Errorf("Unknown transaction must not be found")
+	}
+	if _, err := batch.NewTxProof(len(batch.Transactions)); err == nil {
+		t.Errorf("Out of range transaction must not be proven")
+	}
+}
+
+func TestTxEnvelope(t *testing.T) {
+	batch := testBatch(t, 3)
+	transfer := batch.Transactions[0]
+	enc, err := transfer.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, _ := rlp.EncodeToBytes([]interface{}{transfer.Value, transfer.Gas, transfer.To, transfer.From, transfer.Nonce, transfer.V, transfer.R, transfer.S, transfer.Token})
+	if !bytes.Equal(enc, legacy) {
+		t.Errorf("Transfers must keep the legacy RLP list encoding")
+	}
+	typed := batch.Transactions[1]
+	typed.Type, typed.Data = 0x42, []byte{1, 2, 3}
+	signer := NewRollupSigner(big.NewInt(1337), common.Address{})
+	if signer.Hash(&typed) == signer.Hash(&batch.Transactions[1]) {
+		t.Errorf("Typed transactions must sign their type and data")
+	}
+	enc, err = typed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != 0x42 {
+		t.Errorf("Envelope starts with %x; want the type byte", enc[0])
+	}
+	var decoded Transaction
+	if err := decoded.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hash() != typed.Hash() || decoded.Type != typed.Type || !bytes.Equal(decoded.Data, typed.Data) {
+		t.Errorf("Decoded transaction = %v; want %v", decoded, typed)
+	}
+	//kinds unknown by the node must not break the batch decoding
+	batch.Transactions[1] = typed
+	for _, version := range []byte{LegacyBatchVersion, CompactBatchVersion, DeflateBatchVersion} {
+		enc, err := EncodeBatch(&batch, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := DecodeBatch(enc)
+		if err != nil {
+			t.Fatalf("Version %v: %v", version, err)
+		}
+		if b.Transactions[1].Type != typed.Type || b.Transactions[1].Hash() != typed.Hash() || b.Transactions[0].Type != TransferTxType {
+			t.Errorf("Version %v: decoded transactions = %v", version, b.Transactions)
+		}
+	}
+	enc, err = batch.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := UnMarshalBatch(enc)
+	if err != nil || b.Transactions[1].Hash() != typed.Hash() {
+		t.Errorf("UnMarshalBatch = %v, %v", b, err)
+	}
+	if err := decoded.UnmarshalBinary(append([]byte{0x00}, enc...)); err == nil {
+		t.Errorf("Transfers must not use the typed envelope")
+	}
+}
+
+func TestMultiSendCodec(t *testing.T) {
+	transfers := testBatch(t, 60)
+	payments := make([]Payment, len(transfers.Transactions))
+	for i, tx := range transfers.Transactions {
+		payments[i] = Payment{To: tx.To, Value: tx.Value}
+	}
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	tx, err := NewMultiSendTx(from, Ether, payments, big.NewInt(1e12), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err = SignTx(tx, NewRollupSigner(big.NewInt(1337), common.Address{}), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := tx.Payments(); err != nil || len(decoded) != len(payments) {
+		t.Fatalf("Payments = %v, %v", decoded, err)
+	}
+	batch := Batch{Transactions: []Transaction{*tx}}
+	for _, version := range []byte{LegacyBatchVersion, CompactBatchVersion} {
+		enc, err := EncodeBatch(&batch, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeBatch(enc)
+		if err != nil {
+			t.Fatalf("Version %v: %v", version, err)
+		}
+		if decoded.Transactions[0].Hash() != tx.Hash() {
+			t.Errorf("Version %v: decoded multi-send = %v; want %v", version, decoded.Transactions[0], tx)
+		}
+		single, _ := EncodeBatch(&transfers, version)
+		if len(enc) >= len(single) {
+			t.Errorf("Version %v: multi-send batch is %d bytes; want less than the %d bytes of the transfers", version, len(enc), len(single))
+		}
+	}
+	tx.Data = append(tx.Data, 0)
+	if _, err := tx.Payments(); err == nil {
+		t.Errorf("Malformed payments must be rejected")
+	}
+	if _, err := EncodeBatch(&Batch{Transactions: []Transaction{*tx}}, CompactBatchVersion); err == nil {
+		t.Errorf("Malformed payments can not be compacted")
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	deposit := DepositEvent{EventPosition: EventPosition{BlockNumber: 2, LogIndex: 1}, Deposit: Deposit{From: address1, Value: big.NewInt(1)}}
+	streamErr := errors.New("query failed")
+	tests := []struct {
+		events []Event
+		err    error
+		want   int
+		wantFn func(error) bool
+	}{
+		{[]Event{deposit, L1Block{Number: 2}, L1Block{Number: 3}}, nil, 3, func(err error) bool { return err == nil }},
+		{[]Event{deposit, L1Block{Number: 2}}, streamErr, 2, func(err error) bool { return err == streamErr }},
+		//the marker of a block is after all its events
+		{[]Event{L1Block{Number: 2}, deposit}, nil, 1, func(err error) bool { _, ok := err.(*UnorderedEvent); return ok }},
+		{[]Event{deposit, deposit}, nil, 1, func(err error) bool { _, ok := err.(*UnorderedEvent); return ok }},
+	}
+	for i, test := range tests {
+		stream := NewEventStream(context.Background(), func(ctx context.Context, events chan<- Event) error {
+			for _, event := range test.events {
+				if err := SendEvent(ctx, events, event); err != nil {
+					return err
+				}
+			}
+			return test.err
+		})
+		got := 0
+		for stream.Next() {
+			got++
+		}
+		if got != test.want || !test.wantFn(stream.Err()) {
+			t.Errorf("Test %d: read %d events with error %v; want %d", i, got, stream.Err(), test.want)
+		}
+	}
+	//closing a stream that was not read until the end cancels it
+	done := make(chan error, 1)
+	stream := NewEventStream(context.Background(), func(ctx context.Context, events chan<- Event) error {
+		for {
+			if err := SendEvent(ctx, events, L1Block{}); err != nil {
+				done <- err
+				return err
+			}
+		}
+	})
+	stream.Next()
+	stream.Close()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Stream returned %v; want %v", err, context.Canceled)
+	}
+	if stream.Next() {
+		t.Error("Closed stream returned an event")
+	}
+}
@@ -1,6 +1,8 @@
 package optimisticrp
 
 import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -8,3 +10,11 @@ func Encode(i interface{}) (result []byte, err error) {
 	result, err = rlp.EncodeToBytes(i)
 	return
 }
+
+func rlpHash(x interface{}) common.Hash {
+	enc, err := rlp.EncodeToBytes(x)
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(enc)
+}